@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/JoshPattman/jpf"
+)
+
+// CandidateComparison is the model's verdict on which of two candidates better fits a view.
+type CandidateComparison struct {
+	// First and Second are the indexes of the compared candidates, in the order shown.
+	First  int
+	Second int
+	// FirstIsBetter is true if the model judged the first candidate to be the better fit.
+	FirstIsBetter bool
+	Reasoning     string
+}
+
+// Winner returns the index of the candidate that the model judged to be the better fit.
+func (c CandidateComparison) Winner() int {
+	if c.FirstIsBetter {
+		return c.First
+	}
+	return c.Second
+}
+
+// CompareCandidates asks the model to compare every pair of candidates, once in each order.
+func CompareCandidates(logger *slog.Logger, modelBuilder ModelBuilder, prompts ViewPrompts, criteria map[string]string, candidates []Candidate, progress *ViewProgress) ([]CandidateComparison, error) {
+	pairs := make([][2]int, 0, len(candidates)*(len(candidates)-1))
+	for i := range candidates {
+		for j := range candidates {
+			if i != j {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	logger.Info(
+		"Comparing candidates",
+		"num_candidates", len(candidates),
+		"estimated_llm_calls", len(pairs),
+	)
+	mf := buildCompareCandidatesMapFunc(modelBuilder, logger, prompts.ComparisonTemplate)
+	comparisons, err := ParMap(pairs, func(pair [2]int) (CandidateComparison, error) {
+		pairLogger := logger.With("candidate_id", candidates[pair[0]].ID, "other_candidate_id", candidates[pair[1]].ID)
+		result, _, err := mf.Call(context.Background(), candidateComparisonRequest{
+			Criteria:       criteria,
+			JobDescription: prompts.JobDescription,
+			CandidateA:     candidates[pair[0]].ReviewedText,
+			CandidateB:     candidates[pair[1]].ReviewedText,
+		})
+		progress.Done()
+		if err != nil {
+			pairLogger.Error("Failed to compare candidates", "err", err)
+			return CandidateComparison{}, err
+		}
+		item := result[comparisonResponseKey]
+		pairLogger.Debug("Compared candidates", "first_is_better", item.Answer)
+		return CandidateComparison{First: pair[0], Second: pair[1], FirstIsBetter: item.Answer, Reasoning: item.Reasoning}, nil
+	})
+	if err != nil {
+		// Failed comparisons are left as the zero value, which compares the first candidate with itself, so they are dropped.
+		succeeded := make([]CandidateComparison, 0, len(comparisons))
+		for _, c := range comparisons {
+			if c.First != c.Second {
+				succeeded = append(succeeded, c)
+			}
+		}
+		return succeeded, err
+	}
+	return comparisons, nil
+}
+
+// comparisonCriteria returns the checklist questions that candidates are compared on.
+func comparisonCriteria(checklist map[string]ChecklistQuestion) map[string]string {
+	criteria := make(map[string]string, len(checklist))
+	for k, q := range checklist {
+		criteria[k] = q.Question
+	}
+	return criteria
+}
+
+// numComparisons returns the number of LLM calls CompareCandidates makes for n candidates.
+func numComparisons(candidates int) int {
+	return candidates * (candidates - 1)
+}
+
+// EstimateComparisonUsage estimates the usage of comparing the topN longest resumes.
+func EstimateComparisonUsage(prompts ViewPrompts, criteria map[string]string, candidates []Candidate, topN int) (jpf.Usage, error) {
+	longest := slices.SortedFunc(slices.Values(candidates), func(a, b Candidate) int { return len(b.ReviewedText) - len(a.ReviewedText) })
+	longest = longest[:min(topN, len(longest))]
+	enc := buildCompareCandidatesEncoder(prompts.ComparisonTemplate)
+	usage := jpf.Usage{}
+	for i := range longest {
+		for j := range longest {
+			if i == j {
+				continue
+			}
+			msgs, err := enc.BuildInputMessages(candidateComparisonRequest{
+				Criteria:       criteria,
+				JobDescription: prompts.JobDescription,
+				CandidateA:     longest[i].ReviewedText,
+				CandidateB:     longest[j].ReviewedText,
+			})
+			if err != nil {
+				return jpf.Usage{}, err
+			}
+			usage = usage.Add(jpf.Usage{
+				InputTokens:     estimateMessagesTokens(msgs),
+				OutputTokens:    estimatedComparisonOutputTokens,
+				SuccessfulCalls: 1,
+			})
+		}
+	}
+	return usage, nil
+}
+
+// estimatedComparisonOutputTokens is a rough guess of the output tokens of a comparison.
+const estimatedComparisonOutputTokens = 200
+
+type candidateComparisonRequest struct {
+	Criteria       map[string]string
+	JobDescription string
+	CandidateA     string
+	CandidateB     string
+}
+
+type candidateComparer jpf.MapFunc[candidateComparisonRequest, map[string]responseItem[bool]]
+
+// comparisonResponseKey is the only key of a comparison response.
+const comparisonResponseKey = "candidate_a_is_better"
+
+// buildCompareCandidatesEncoder builds the comparison encoder from tmpl, or the built-in one.
+func buildCompareCandidatesEncoder(tmpl string) jpf.MessageEncoder[candidateComparisonRequest] {
+	if tmpl == "" {
+		tmpl = simpleCandidateComparisonTemplate
+	}
+	return jpf.NewTemplateMessageEncoder[candidateComparisonRequest](
+		"",
+		tmpl,
+	)
+}
+
+// ValidateComparisonTemplate checks that a custom comparison template renders.
+func ValidateComparisonTemplate(tmpl string, requireJobDescription bool) error {
+	example := func(jobDesc string) candidateComparisonRequest {
+		return candidateComparisonRequest{
+			Criteria:       map[string]string{"example": "Is this an example?"},
+			JobDescription: jobDesc,
+			CandidateA:     "Example resume A",
+			CandidateB:     "Example resume B",
+		}
+	}
+	if err := validatePromptTemplate(tmpl, example("Example job description")); err != nil {
+		return err
+	}
+	if requireJobDescription {
+		return validateJobDescriptionPromptTemplate(tmpl, example("Example job description"), example("Another job description"))
+	}
+	return nil
+}
+
+// comparisonResponseSchema is the JSON schema that a response to a comparison must match.
+func comparisonResponseSchema() map[string]any {
+	return objectSchema(schemaProperty{comparisonResponseKey, objectSchema(
+		schemaProperty{"reasoning", map[string]any{"type": "string"}},
+		schemaProperty{"answer", map[string]any{"type": "boolean"}},
+	)})
+}
+
+// Build a mapfunc (a typed LLM call with retry logic) for comparing two candidates.
+func buildCompareCandidatesMapFunc(modelBuilder ModelBuilder, logger *slog.Logger, tmpl string) candidateComparer {
+	enc := buildCompareCandidatesEncoder(tmpl)
+	dec := newKeyedResponseDecoder[candidateComparisonRequest, bool](
+		func(candidateComparisonRequest) []string {
+			return []string{comparisonResponseKey}
+		},
+		"a JSON boolean (true or false)",
+	)
+	fed := jpf.NewRawMessageFeedbackGenerator()
+	model := modelBuilder.BuildCandidateReviewModel(logger, comparisonResponseSchema())
+	return jpf.NewFeedbackMapFunc(enc, dec, fed, model, jpf.UserRole, 10)
+}
+
+const simpleCandidateComparisonTemplate = `You are an expert candidate reviewer. Compare the two resumes below carefully and decide which candidate is the better fit for the role.
+{{ if .JobDescription }}
+The role has the following job description:
+{{ .JobDescription }}
+{{ end }}
+Judge the candidates on the following criteria:
+{{ range $k, $v := .Criteria }}
+* {{$v}}
+{{ end }}
+Do not let the order that the resumes are shown in affect your decision.
+
+Return a single JSON object with exactly one key:
+- candidate_a_is_better: an object with "reasoning" (your full reasoning comparing the two candidates) and "answer" (true or false, true if candidate A is the better fit, false if candidate B is)
+
+You must pick one candidate, even if they are very close.
+
+Candidate A resume:
+{{ .CandidateA }}
+
+Candidate B resume:
+{{ .CandidateB }}`
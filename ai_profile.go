@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JoshPattman/jpf"
+)
+
+// CandidateProfile is the structured information extracted from a candidate's CV.
+type CandidateProfile struct {
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	Phone    string   `json:"phone"`
+	Location string   `json:"location"`
+	Links    []string `json:"links"`
+	// Roles are the candidate's jobs, most recent first.
+	Roles []ProfileRole `json:"roles"`
+	// Education is the candidate's qualifications, most recent first.
+	Education []ProfileEducation `json:"education"`
+	Skills    []string           `json:"skills"`
+	Languages []string           `json:"languages"`
+}
+
+// ProfileRole is one job in a candidate's employment history.
+type ProfileRole struct {
+	Title    string `json:"title"`
+	Employer string `json:"employer"`
+	// StartDate and EndDate are YYYY-MM or YYYY, and EndDate may be "present".
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// ProfileEducation is one qualification in a candidate's education.
+type ProfileEducation struct {
+	Institution   string `json:"institution"`
+	Qualification string `json:"qualification"`
+	Field         string `json:"field"`
+	// EndDate is YYYY-MM, YYYY or "present", or empty if it is not known.
+	EndDate string `json:"end_date"`
+}
+
+// profileDatePresent is the end date of a role or qualification that has not ended.
+const profileDatePresent = "present"
+
+// profileDatePattern matches the dates allowed in a profile, other than profileDatePresent.
+var profileDatePattern = regexp.MustCompile(`^\d{4}(-(0[1-9]|1[0-2]))?$`)
+
+// CurrentRole returns the first of the candidate's current roles, or false if they do not have one.
+func (p CandidateProfile) CurrentRole() (ProfileRole, bool) {
+	for _, r := range p.Roles {
+		if r.EndDate == profileDatePresent {
+			return r, true
+		}
+	}
+	return ProfileRole{}, false
+}
+
+// ExperienceYears returns the years covered by the candidate's roles, counting overlaps once.
+func (p CandidateProfile) ExperienceYears(now time.Time) float64 {
+	type span struct{ start, end int }
+	nowMonth, _ := profileMonth(profileDatePresent, true, now)
+	spans := make([]span, 0, len(p.Roles))
+	for _, r := range p.Roles {
+		start, ok := profileMonth(r.StartDate, false, now)
+		if !ok {
+			continue
+		}
+		end, ok := profileMonth(r.EndDate, true, now)
+		if !ok {
+			continue
+		}
+		end = min(end, nowMonth)
+		if end < start {
+			continue
+		}
+		spans = append(spans, span{start, end + 1})
+	}
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+	months, coveredUntil := 0, math.MinInt
+	for _, s := range spans {
+		start := max(s.start, coveredUntil)
+		if s.end > start {
+			months += s.end - start
+		}
+		coveredUntil = max(coveredUntil, s.end)
+	}
+	return math.Round(float64(months)/12*10) / 10
+}
+
+// profileMonth converts a profile date to a number of months since year 0.
+func profileMonth(date string, isEnd bool, now time.Time) (int, bool) {
+	if date == profileDatePresent {
+		return now.Year()*12 + int(now.Month()) - 1, isEnd
+	}
+	if !profileDatePattern.MatchString(date) {
+		return 0, false
+	}
+	year, _ := strconv.Atoi(date[:4])
+	month := 1
+	if isEnd {
+		month = 12
+	}
+	if len(date) > 4 {
+		month, _ = strconv.Atoi(date[5:])
+	}
+	return year*12 + month - 1, true
+}
+
+// profileColumns are the profile fields that can be added to reports as "profile_" columns.
+var profileColumns = map[string]func(p CandidateProfile, now time.Time) string{
+	"name":     func(p CandidateProfile, _ time.Time) string { return p.Name },
+	"email":    func(p CandidateProfile, _ time.Time) string { return p.Email },
+	"phone":    func(p CandidateProfile, _ time.Time) string { return p.Phone },
+	"location": func(p CandidateProfile, _ time.Time) string { return p.Location },
+	"links":    func(p CandidateProfile, _ time.Time) string { return strings.Join(p.Links, ";") },
+	"current_title": func(p CandidateProfile, _ time.Time) string {
+		r, _ := p.CurrentRole()
+		return r.Title
+	},
+	"current_employer": func(p CandidateProfile, _ time.Time) string {
+		r, _ := p.CurrentRole()
+		return r.Employer
+	},
+	"years_experience": func(p CandidateProfile, now time.Time) string {
+		return strconv.FormatFloat(p.ExperienceYears(now), 'f', -1, 64)
+	},
+	"education": func(p CandidateProfile, _ time.Time) string {
+		parts := make([]string, len(p.Education))
+		for i, e := range p.Education {
+			parts[i] = strings.TrimSpace(strings.Join(slices.DeleteFunc([]string{e.Qualification, e.Field, e.Institution, e.EndDate}, func(s string) bool { return s == "" }), " "))
+		}
+		return strings.Join(parts, ";")
+	},
+	"skills":    func(p CandidateProfile, _ time.Time) string { return strings.Join(p.Skills, ";") },
+	"languages": func(p CandidateProfile, _ time.Time) string { return strings.Join(p.Languages, ";") },
+}
+
+// profileReportColumnPrefix is prepended to the names of profile fields in report headers.
+const profileReportColumnPrefix = "profile_"
+
+// isProfileReportColumn returns true if the column is a profile report column.
+func isProfileReportColumn(column string) bool {
+	field, ok := strings.CutPrefix(column, profileReportColumnPrefix)
+	_, exists := profileColumns[field]
+	return ok && exists
+}
+
+// ExtractProfiles asks the model to extract a profile from each candidate's original CV.
+func ExtractProfiles(logger *slog.Logger, modelBuilder ModelBuilder, candidates []Candidate, progress *ViewProgress) ([]*CandidateProfile, error) {
+	logger.Info(
+		"Extracting profiles",
+		"num_resumes", len(candidates),
+		"estimated_llm_calls", len(candidates),
+	)
+	return ParMapRange(
+		len(candidates),
+		func(i int) (*CandidateProfile, error) {
+			candidateLogger := logger.With("candidate_id", candidates[i].ID)
+			mf := buildExtractProfileMapFunc(modelBuilder, candidateLogger)
+			profile, _, err := mf.Call(context.Background(), profileRequest{Resume: candidates[i].RedactedText})
+			progress.Done()
+			if err != nil {
+				candidateLogger.Error("Failed to extract profile", "err", err)
+				return nil, err
+			}
+			profile = rehydrateProfile(profile, candidates[i].PIIMapping)
+			candidateLogger.Debug("Extracted profile", "profile", profile)
+			return &profile, nil
+		},
+	)
+}
+
+// rehydrateProfile restores the PII placeholders in every field of the profile.
+func rehydrateProfile(p CandidateProfile, mapping PIIMapping) CandidateProfile {
+	if len(mapping) == 0 {
+		return p
+	}
+	list := func(items []string) []string {
+		out := make([]string, len(items))
+		for i, s := range items {
+			out[i] = mapping.Rehydrate(s)
+		}
+		return out
+	}
+	p.Name = mapping.Rehydrate(p.Name)
+	p.Email = mapping.Rehydrate(p.Email)
+	p.Phone = mapping.Rehydrate(p.Phone)
+	p.Location = mapping.Rehydrate(p.Location)
+	p.Links = list(p.Links)
+	p.Skills = list(p.Skills)
+	p.Languages = list(p.Languages)
+	roles := make([]ProfileRole, len(p.Roles))
+	for i, r := range p.Roles {
+		roles[i] = ProfileRole{Title: mapping.Rehydrate(r.Title), Employer: mapping.Rehydrate(r.Employer), StartDate: r.StartDate, EndDate: r.EndDate}
+	}
+	p.Roles = roles
+	education := make([]ProfileEducation, len(p.Education))
+	for i, e := range p.Education {
+		education[i] = ProfileEducation{Institution: mapping.Rehydrate(e.Institution), Qualification: mapping.Rehydrate(e.Qualification), Field: mapping.Rehydrate(e.Field), EndDate: e.EndDate}
+	}
+	p.Education = education
+	return p
+}
+
+// estimatedProfileOutputTokens is a rough guess of the output tokens of a profile.
+const estimatedProfileOutputTokens = 600
+
+// EstimateProfileUsage estimates the usage of extracting every candidate's profile.
+func EstimateProfileUsage(candidates []Candidate) (jpf.Usage, error) {
+	enc := buildExtractProfileEncoder()
+	usage := jpf.Usage{}
+	for _, c := range candidates {
+		msgs, err := enc.BuildInputMessages(profileRequest{Resume: c.RedactedText})
+		if err != nil {
+			return jpf.Usage{}, err
+		}
+		usage = usage.Add(jpf.Usage{
+			InputTokens:     estimateMessagesTokens(msgs),
+			OutputTokens:    estimatedProfileOutputTokens,
+			SuccessfulCalls: 1,
+		})
+	}
+	return usage, nil
+}
+
+type profileRequest struct {
+	Resume string
+}
+
+type profileExtractor jpf.MapFunc[profileRequest, CandidateProfile]
+
+// validateProfile checks the format of the dates of the extracted profile.
+func validateProfile(_ profileRequest, p CandidateProfile) error {
+	problems := make([]string, 0)
+	checkDate := func(path string, date string, allowPresent bool) {
+		if date == "" || profileDatePattern.MatchString(date) || (allowPresent && date == profileDatePresent) {
+			return
+		}
+		problems = append(problems, fmt.Sprintf("%s %q must be YYYY-MM, YYYY, %q, or an empty string if it is not known", path, date, profileDatePresent))
+	}
+	for i, r := range p.Roles {
+		if strings.TrimSpace(r.Title) == "" && strings.TrimSpace(r.Employer) == "" {
+			problems = append(problems, fmt.Sprintf("roles[%d] must have a title or an employer", i))
+		}
+		checkDate(fmt.Sprintf("roles[%d].start_date", i), r.StartDate, false)
+		checkDate(fmt.Sprintf("roles[%d].end_date", i), r.EndDate, true)
+	}
+	for i, e := range p.Education {
+		checkDate(fmt.Sprintf("education[%d].end_date", i), e.EndDate, true)
+	}
+	return problemsError(problems)
+}
+
+// profileResponseSchema is the JSON schema that an extracted profile must match.
+func profileResponseSchema() map[string]any {
+	str := map[string]any{"type": "string"}
+	strList := map[string]any{"type": "array", "items": str}
+	return objectSchema(
+		schemaProperty{"name", str},
+		schemaProperty{"email", str},
+		schemaProperty{"phone", str},
+		schemaProperty{"location", str},
+		schemaProperty{"links", strList},
+		schemaProperty{"roles", map[string]any{
+			"type": "array",
+			"items": objectSchema(
+				schemaProperty{"title", str},
+				schemaProperty{"employer", str},
+				schemaProperty{"start_date", str},
+				schemaProperty{"end_date", str},
+			),
+		}},
+		schemaProperty{"education", map[string]any{
+			"type": "array",
+			"items": objectSchema(
+				schemaProperty{"institution", str},
+				schemaProperty{"qualification", str},
+				schemaProperty{"field", str},
+				schemaProperty{"end_date", str},
+			),
+		}},
+		schemaProperty{"skills", strList},
+		schemaProperty{"languages", strList},
+	)
+}
+
+func buildExtractProfileEncoder() jpf.MessageEncoder[profileRequest] {
+	return jpf.NewTemplateMessageEncoder[profileRequest](
+		"",
+		simpleExtractProfileTemplate,
+	)
+}
+
+// Build a mapfunc (a typed LLM call with retry logic) for extracting a candidate's profile.
+func buildExtractProfileMapFunc(modelBuilder ModelBuilder, logger *slog.Logger) profileExtractor {
+	enc := buildExtractProfileEncoder()
+	dec := jpf.NewJsonResponseDecoder[profileRequest, CandidateProfile]()
+	dec = wrapJsonDecoder(dec)
+	dec = jpf.NewValidatingResponseDecoder(dec, validateProfile)
+	fed := jpf.NewRawMessageFeedbackGenerator()
+	model := modelBuilder.BuildCandidateReviewModel(logger, profileResponseSchema())
+	return jpf.NewFeedbackMapFunc(enc, dec, fed, model, jpf.UserRole, 10)
+}
+
+const simpleExtractProfileTemplate = `You are an expert at reading resumes. Extract the candidate's details from the resume below into a single JSON object with the following keys:
+- "name": the candidate's full name
+- "email": their email address
+- "phone": their phone number, as written
+- "location": where they are based, such as a city and country
+- "links": URLs of their website, LinkedIn, GitHub and similar profiles
+- "roles": every job they have held, most recent first, each an object with:
+    - "title": their job title
+    - "employer": the name of the company or organisation
+    - "start_date": when they started, as YYYY-MM, or YYYY if the month is not given
+    - "end_date": when they finished, in the same format, or "present" if it is their current job
+- "education": every qualification, most recent first, each an object with:
+    - "institution": the university, school or other institution
+    - "qualification": the qualification, such as "BSc" or "A-Levels"
+    - "field": the subject studied
+    - "end_date": when they finished, as YYYY-MM or YYYY, or "present" if they are still studying
+- "skills": their technical and professional skills, each a short name such as "Python" or "Project management"
+- "languages": the spoken languages they know, such as "English" or "French (fluent)"
+
+Only include information that is in the resume. Use an empty string for anything that is not given, such as an unknown date, and an empty list if there is nothing to list.
+
+Resume:
+{{ .Resume }}`
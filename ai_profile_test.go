@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExperienceYears(t *testing.T) {
+	now := time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		roles []ProfileRole
+		want  float64
+	}{
+		{"no roles", nil, 0},
+		{"one role", []ProfileRole{{StartDate: "2020-01", EndDate: "2021-12"}}, 2},
+		{"same month", []ProfileRole{{StartDate: "2020-03", EndDate: "2020-03"}}, 0.1},
+		{"present", []ProfileRole{{StartDate: "2025-07", EndDate: "present"}}, 1},
+		{"year-only dates", []ProfileRole{{StartDate: "2018", EndDate: "2019"}}, 2},
+		{"year-only end in the current year", []ProfileRole{{StartDate: "2025-07", EndDate: "2026"}}, 1},
+		{"end date in the future", []ProfileRole{{StartDate: "2025-07", EndDate: "2030-01"}}, 1},
+		{"start date in the future", []ProfileRole{{StartDate: "2027-01", EndDate: "present"}}, 0},
+		{"overlapping roles", []ProfileRole{{StartDate: "2020-01", EndDate: "2021-12"}, {StartDate: "2021-01", EndDate: "2022-12"}}, 3},
+		{"role within another", []ProfileRole{{StartDate: "2020-01", EndDate: "2022-12"}, {StartDate: "2021-01", EndDate: "2021-06"}}, 3},
+		{"gap between roles", []ProfileRole{{StartDate: "2022-01", EndDate: "2022-12"}, {StartDate: "2019-01", EndDate: "2019-12"}}, 2},
+		{"overlapping present roles", []ProfileRole{{StartDate: "2024-07", EndDate: "present"}, {StartDate: "2025-07", EndDate: "present"}}, 2},
+		{"missing dates", []ProfileRole{{StartDate: "", EndDate: "2020"}, {StartDate: "2019", EndDate: ""}}, 0},
+		{"unparseable dates", []ProfileRole{{StartDate: "Jan 2020", EndDate: "present"}, {StartDate: "2020-13", EndDate: "present"}, {StartDate: "2019", EndDate: "now"}}, 0},
+		{"present start date", []ProfileRole{{StartDate: "present", EndDate: "present"}}, 0},
+		{"end before start", []ProfileRole{{StartDate: "2022-01", EndDate: "2021-01"}}, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := (CandidateProfile{Roles: c.roles}).ExperienceYears(now); got != c.want {
+				t.Errorf("ExperienceYears() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	cases := []struct {
+		name         string
+		profile      CandidateProfile
+		wantProblems []string
+	}{
+		{"empty", CandidateProfile{}, nil},
+		{"valid", CandidateProfile{
+			Roles:     []ProfileRole{{Title: "Engineer", StartDate: "2020-01", EndDate: "present"}, {Employer: "Acme", StartDate: "2018", EndDate: ""}},
+			Education: []ProfileEducation{{Institution: "MIT", EndDate: "present"}, {Institution: "School", EndDate: "2015-06"}},
+		}, nil},
+		{"role without title or employer", CandidateProfile{Roles: []ProfileRole{{Title: " ", StartDate: "2020"}}}, []string{"roles[0] must have a title or an employer"}},
+		{"bad start date", CandidateProfile{Roles: []ProfileRole{{Title: "Engineer", StartDate: "Jan 2020"}}}, []string{`roles[0].start_date "Jan 2020" must be YYYY-MM`}},
+		{"present start date", CandidateProfile{Roles: []ProfileRole{{Title: "Engineer", StartDate: "present"}}}, []string{`roles[0].start_date "present"`}},
+		{"bad month", CandidateProfile{Roles: []ProfileRole{{Title: "Engineer", EndDate: "2020-13"}}}, []string{`roles[0].end_date "2020-13"`}},
+		{"bad education date", CandidateProfile{Education: []ProfileEducation{{}, {EndDate: "2020/06"}}}, []string{`education[1].end_date "2020/06"`}},
+		{"every problem", CandidateProfile{
+			Roles:     []ProfileRole{{StartDate: "x", EndDate: "y"}},
+			Education: []ProfileEducation{{EndDate: "z"}},
+		}, []string{"roles[0] must have", "roles[0].start_date", "roles[0].end_date", "education[0].end_date"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateProfile(profileRequest{}, c.profile)
+			if c.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("validateProfile() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateProfile() error = nil, want problems %q", c.wantProblems)
+			}
+			if got := strings.Count(err.Error(), "\n- "); got != len(c.wantProblems) {
+				t.Errorf("error has %d problems, want %d: %v", got, len(c.wantProblems), err)
+			}
+			for _, p := range c.wantProblems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("error = %v, want it to contain %q", err, p)
+				}
+			}
+		})
+	}
+}
+
+func TestRehydrateProfile(t *testing.T) {
+	mapping := PIIMapping{"[NAME_1]": "Alice Smith", "[EMAIL_1]": "alice@example.com", "[PHONE_1]": "07700 900123", "[URL_1]": "https://alice.dev"}
+	redacted := CandidateProfile{
+		Name:      "[NAME_1]",
+		Email:     "[EMAIL_1]",
+		Phone:     "[PHONE_1]",
+		Location:  "London",
+		Links:     []string{"[URL_1]", "https://github.com/[NAME_1]"},
+		Roles:     []ProfileRole{{Title: "Engineer", Employer: "[NAME_1] Consulting", StartDate: "2020-01", EndDate: "present"}},
+		Education: []ProfileEducation{{Institution: "MIT", Qualification: "BSc", Field: "Physics", EndDate: "2019"}},
+		Skills:    []string{"Go"},
+		Languages: []string{"English"},
+	}
+	want := CandidateProfile{
+		Name:      "Alice Smith",
+		Email:     "alice@example.com",
+		Phone:     "07700 900123",
+		Location:  "London",
+		Links:     []string{"https://alice.dev", "https://github.com/Alice Smith"},
+		Roles:     []ProfileRole{{Title: "Engineer", Employer: "Alice Smith Consulting", StartDate: "2020-01", EndDate: "present"}},
+		Education: []ProfileEducation{{Institution: "MIT", Qualification: "BSc", Field: "Physics", EndDate: "2019"}},
+		Skills:    []string{"Go"},
+		Languages: []string{"English"},
+	}
+	if got := rehydrateProfile(redacted, mapping); !reflect.DeepEqual(got, want) {
+		t.Errorf("rehydrateProfile() = %+v, want %+v", got, want)
+	}
+	if redacted.Links[0] != "[URL_1]" || redacted.Roles[0].Employer != "[NAME_1] Consulting" {
+		t.Errorf("rehydrateProfile() changed the original profile to %+v", redacted)
+	}
+	if got := rehydrateProfile(redacted, PIIMapping{}); !reflect.DeepEqual(got, redacted) {
+		t.Errorf("rehydrateProfile() with no mapping = %+v, want it unchanged", got)
+	}
+}
+
+func TestExtractProfilesKeepsSuccesses(t *testing.T) {
+	cases := []struct {
+		name    string
+		failure error
+		wantErr bool
+	}{
+		{"failed candidate is skipped", errors.New("500 internal server error"), false},
+		{"budget running out is returned", ErrBudgetExceeded, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			model := fakeModel{respond: func(prompt string) (string, error) {
+				if strings.Contains(prompt, "Resume of Bob") {
+					return "", c.failure
+				}
+				name := "Alice"
+				if strings.Contains(prompt, "Resume of Carol") {
+					name = "Carol"
+				}
+				return fmt.Sprintf(`{"name": %q}`, name), nil
+			}}
+			candidates := []Candidate{{ID: "a", RedactedText: "Resume of Alice"}, {ID: "b", RedactedText: "Resume of Bob"}, {ID: "c", RedactedText: "Resume of Carol"}}
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			err := extractProfiles(logger, fakeModelBuilder{model}, candidates, NewProgress())
+			if (err != nil) != c.wantErr || (err != nil && !isBudgetExceeded(err)) {
+				t.Fatalf("extractProfiles() error = %v, want a budget error %v", err, c.wantErr)
+			}
+			if candidates[0].Profile == nil || candidates[0].Profile.Name != "Alice" || candidates[2].Profile == nil || candidates[2].Profile.Name != "Carol" {
+				t.Errorf("profiles = %+v, %+v, want the successful profiles kept", candidates[0].Profile, candidates[2].Profile)
+			}
+			if candidates[1].Profile != nil {
+				t.Errorf("failed candidate's profile = %+v, want nil", candidates[1].Profile)
+			}
+		})
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/JoshPattman/jpf"
 )
@@ -11,28 +15,129 @@ import (
 type CandidateTextQuestionResult struct {
 	Reasoning string
 	Answer    string
+	// Votes counts how many repeats gave each choice, and is nil for free-text questions.
+	Votes map[string]int `json:",omitempty"`
 }
 
-func AnswerQuestionsForCandidates(logger *slog.Logger, modelBuilder ModelBuilder, questions map[string]string, resumes []string) ([]map[string]CandidateTextQuestionResult, error) {
-	if len(resumes) == 0 {
+// Inconsistency returns how much the repeats of a multiple-choice question disagreed, from 0 to 1.
+func (r CandidateTextQuestionResult) Inconsistency() float64 {
+	total, most := 0, 0
+	for _, n := range r.Votes {
+		total += n
+		most = max(most, n)
+	}
+	if total == 0 || len(r.Votes) < 2 {
+		return 0
+	}
+	choices := float64(len(r.Votes))
+	return (1 - float64(most)/float64(total)) * choices / (choices - 1)
+}
+
+// VoteDistribution describes the share of the votes of each choice, such as "Senior=0.6;Mid=0.4".
+func (r CandidateTextQuestionResult) VoteDistribution() string {
+	total := 0
+	for _, n := range r.Votes {
+		total += n
+	}
+	if total == 0 {
+		return ""
+	}
+	choices := slices.SortedFunc(maps.Keys(r.Votes), func(a, b string) int {
+		if r.Votes[a] != r.Votes[b] {
+			return r.Votes[b] - r.Votes[a]
+		}
+		return strings.Compare(a, b)
+	})
+	parts := make([]string, 0, len(choices))
+	for _, c := range choices {
+		if r.Votes[c] > 0 {
+			parts = append(parts, c+"="+strconv.FormatFloat(float64(r.Votes[c])/float64(total), 'f', -1, 64))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// SpecificQuestion is a specific question as it is put to the model.
+type SpecificQuestion struct {
+	Question string
+	// Choices are the only answers the model may give, or nil for a free-text question.
+	Choices []string
+}
+
+// Prompt returns the question as shown to the model, including any choices.
+func (q SpecificQuestion) Prompt() string {
+	if len(q.Choices) == 0 {
+		return q.Question
+	}
+	quoted := make([]string, len(q.Choices))
+	for i, c := range q.Choices {
+		quoted[i] = strconv.Quote(c)
+	}
+	return fmt.Sprintf("%s (answer with exactly one of %s)", q.Question, strings.Join(quoted, ", "))
+}
+
+// questionPrompts maps each question key to the question shown to the model.
+func questionPrompts(questions map[string]SpecificQuestion) map[string]string {
+	prompts := make(map[string]string, len(questions))
+	for k, q := range questions {
+		prompts[k] = q.Prompt()
+	}
+	return prompts
+}
+
+// choiceQuestions returns only the multiple-choice questions.
+func choiceQuestions(questions map[string]SpecificQuestion) map[string]SpecificQuestion {
+	choices := make(map[string]SpecificQuestion)
+	for k, q := range questions {
+		if len(q.Choices) > 0 {
+			choices[k] = q
+		}
+	}
+	return choices
+}
+
+// numQuestionCalls returns the number of LLM calls to answer the questions for one candidate.
+func numQuestionCalls(questions map[string]SpecificQuestion, numRepeats int) int {
+	if len(questions) == 0 {
+		return 0
+	}
+	if len(choiceQuestions(questions)) > 0 {
+		return numRepeats
+	}
+	return 1
+}
+
+// AnswerQuestionsForCandidates answers the specific questions about each candidate.
+func AnswerQuestionsForCandidates(logger *slog.Logger, modelBuilder ModelBuilder, prompts ViewPrompts, questions map[string]SpecificQuestion, candidates []Candidate, useOriginalText bool, numRepeats int, progress *ViewProgress) ([]map[string]CandidateTextQuestionResult, error) {
+	if len(candidates) == 0 {
 		logger.Info("No resumes provided for question answering, skipping")
 		return []map[string]CandidateTextQuestionResult{}, nil
 	}
 	if len(questions) == 0 {
 		logger.Info("No questions provided for question answering, skipping")
-		return make([]map[string]CandidateTextQuestionResult, len(resumes)), nil
+		results := make([]map[string]CandidateTextQuestionResult, len(candidates))
+		for i := range results {
+			results[i] = map[string]CandidateTextQuestionResult{}
+		}
+		return results, nil
 	}
 	task := &candidateQuestionsTask{
 		logger:       logger,
 		modelBuilder: modelBuilder,
+		template:     prompts.QuestionTemplate,
+		jobDesc:      prompts.JobDescription,
 		questions:    questions,
-		resumes:      resumes,
+		candidates:   candidates,
+		useOriginal:  useOriginalText,
+		repeats:      numQuestionCalls(questions, numRepeats),
+		progress:     progress,
 	}
 	logger.Info(
 		"Answering questions",
-		"num_resumes", len(resumes),
+		"num_resumes", len(candidates),
 		"num_questions", len(questions),
-		"estimated_llm_calls", len(resumes),
+		"num_repeats", task.repeats,
+		"estimated_llm_calls", len(candidates)*task.repeats,
 	)
 	return task.execute()
 }
@@ -40,18 +145,23 @@ func AnswerQuestionsForCandidates(logger *slog.Logger, modelBuilder ModelBuilder
 type candidateQuestionsTask struct {
 	logger       *slog.Logger
 	modelBuilder ModelBuilder
-	questions    map[string]string
-	resumes      []string
+	template     string
+	jobDesc      string
+	questions    map[string]SpecificQuestion
+	candidates   []Candidate
+	useOriginal  bool
+	repeats      int
+	progress     *ViewProgress
 }
 
 func (task *candidateQuestionsTask) execute() ([]map[string]CandidateTextQuestionResult, error) {
-	task.logger.Info("Beginning question answering", "num_candidates", len(task.resumes))
+	task.logger.Info("Beginning question answering", "num_candidates", len(task.candidates))
 	return ParMapRange(
-		len(task.resumes),
+		len(task.candidates),
 		func(i int) (map[string]CandidateTextQuestionResult, error) {
-			candidateLogger := task.logger.With("resume", i)
-			candidateLogger.Info("Begun question answering")
-			res, err := task.qaSingleCandidate(i)
+			candidateLogger := task.logger.With("candidate_id", task.candidates[i].ID)
+			candidateLogger.Info("Begun question answering", "file", task.candidates[i].Name)
+			res, err := task.qaSingleCandidate(candidateLogger, i)
 			if err != nil {
 				candidateLogger.Error("Failed to answer questions for candidate", "err", err)
 			} else {
@@ -64,60 +174,204 @@ func (task *candidateQuestionsTask) execute() ([]map[string]CandidateTextQuestio
 }
 
 type candidateQuestionRequest struct {
-	Resume    string
-	Questions map[string]string
+	RepeatNumber   int
+	Resume         string
+	Questions      map[string]string
+	JobDescription string
 }
 
-type candidateQuestionResponse struct {
-	Reasoning string `json:"reasoning"`
-	Answer    string `json:"answer"`
-}
+type candidateQuestioner jpf.MapFunc[candidateQuestionRequest, map[string]responseItem[string]]
 
-type candidateQuestionsResponse map[string]candidateQuestionResponse
-
-type candidateQuestioner jpf.MapFunc[candidateQuestionRequest, candidateQuestionsResponse]
+func (task *candidateQuestionsTask) qaSingleCandidate(logger *slog.Logger, candidateIndex int) (map[string]CandidateTextQuestionResult, error) {
+	// The first repeat answers every question, and the rest only answer the multiple-choice questions, which are voted on.
+	resultsPerRepeat, err := ParMapRange(
+		task.repeats,
+		func(i int) (map[string]responseItem[string], error) {
+			questions := task.questions
+			if i > 0 {
+				questions = choiceQuestions(task.questions)
+			}
+			return task.answerQuestionsOnce(logger.With("repeat", i), candidateIndex, questions, i)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	answers := make(map[string]CandidateTextQuestionResult, len(task.questions))
+	for k, q := range task.questions {
+		if len(q.Choices) > 0 {
+			answers[k] = voteOnChoices(resultsPerRepeat, k, q.Choices)
+		} else {
+			answers[k] = CandidateTextQuestionResult{Reasoning: resultsPerRepeat[0][k].Reasoning, Answer: resultsPerRepeat[0][k].Answer}
+		}
+	}
+	return answers, nil
+}
 
-func (task *candidateQuestionsTask) qaSingleCandidate(candidateIndex int) (map[string]CandidateTextQuestionResult, error) {
-	mf := buildQuestionCandidateMapFunc(task.modelBuilder, task.logger)
+func (task *candidateQuestionsTask) answerQuestionsOnce(logger *slog.Logger, candidateIndex int, questions map[string]SpecificQuestion, repeatNumber int) (map[string]responseItem[string], error) {
+	mf := buildQuestionCandidateMapFunc(task.modelBuilder, logger, task.template, questions)
 	req := candidateQuestionRequest{
-		Resume:    task.resumes[candidateIndex],
-		Questions: task.questions,
+		RepeatNumber:   repeatNumber,
+		Resume:         task.candidates[candidateIndex].QuestionText(task.useOriginal),
+		Questions:      questionPrompts(questions),
+		JobDescription: task.jobDesc,
 	}
 	result, _, err := mf.Call(context.Background(), req)
+	task.progress.Done()
 	if err != nil {
 		return nil, err
 	}
-	answers := make(map[string]CandidateTextQuestionResult)
-	for k, v := range result {
-		answers[k] = CandidateTextQuestionResult(v)
+	return result, nil
+}
+
+// voteOnChoices returns the most common answer of the repeats, with ties going to the earliest.
+func voteOnChoices(resultsPerRepeat []map[string]responseItem[string], key string, choices []string) CandidateTextQuestionResult {
+	votes := make(map[string]int, len(choices))
+	for _, c := range choices {
+		votes[c] = 0
 	}
-	return answers, nil
+	most := 0
+	for _, results := range resultsPerRepeat {
+		if choice, ok := matchChoice(results[key].Answer, choices); ok {
+			votes[choice]++
+			most = max(most, votes[choice])
+		}
+	}
+	// Keep the reasoning of the first repeat that gave the winning answer, so it explains that answer.
+	for _, results := range resultsPerRepeat {
+		if choice, ok := matchChoice(results[key].Answer, choices); ok && votes[choice] == most {
+			return CandidateTextQuestionResult{Reasoning: results[key].Reasoning, Answer: choice, Votes: votes}
+		}
+	}
+	return CandidateTextQuestionResult{Votes: votes}
 }
 
-func buildQuestionCandidateMapFunc(modelBuilder ModelBuilder, logger *slog.Logger) candidateQuestioner {
-	enc := jpf.NewTemplateMessageEncoder[candidateQuestionRequest](
-		"",
-		simpleCandidateQuestionTemplate,
-	)
-	dec := jpf.NewJsonResponseDecoder[candidateQuestionRequest, candidateQuestionsResponse]()
-	dec = wrapJsonDecoder(dec)
-	dec = jpf.NewValidatingResponseDecoder(
-		dec,
-		func(input candidateQuestionRequest, response candidateQuestionsResponse) error {
-			missingKeys := make([]string, 0)
-			for k := range input.Questions {
-				if _, ok := response[k]; !ok {
-					missingKeys = append(missingKeys, k)
-				}
+// matchChoice returns the choice that the answer refers to, ignoring case and surrounding spaces.
+func matchChoice(answer string, choices []string) (string, bool) {
+	answer = strings.TrimSpace(answer)
+	for _, c := range choices {
+		if strings.EqualFold(answer, c) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// validateChoiceAnswers checks that every multiple-choice answer is one of its choices.
+func validateChoiceAnswers(questions map[string]SpecificQuestion, answers map[string]responseItem[string]) error {
+	problems := make([]string, 0)
+	for _, key := range slices.Sorted(maps.Keys(answers)) {
+		choices := questions[key].Choices
+		if len(choices) == 0 {
+			continue
+		}
+		if _, ok := matchChoice(answers[key].Answer, choices); !ok {
+			problems = append(problems, fmt.Sprintf("%q must be answered with exactly one of %q, got %q", key, choices, answers[key].Answer))
+		}
+	}
+	return problemsError(problems)
+}
+
+// estimatedQuestionOutputTokensPerItem is a rough guess of the output tokens of a question.
+const estimatedQuestionOutputTokensPerItem = 100
+
+// EstimateQuestionUsage estimates the usage of answering the questions for every candidate.
+func EstimateQuestionUsage(prompts ViewPrompts, questions map[string]SpecificQuestion, candidates []Candidate, useOriginalText bool, numRepeats int) (jpf.Usage, error) {
+	usage := jpf.Usage{}
+	if len(questions) == 0 {
+		return usage, nil
+	}
+	enc := buildQuestionCandidateEncoder(prompts.QuestionTemplate)
+	for _, c := range candidates {
+		for i := range numQuestionCalls(questions, numRepeats) {
+			repeatQuestions := questions
+			if i > 0 {
+				repeatQuestions = choiceQuestions(questions)
 			}
-			if len(missingKeys) > 0 {
-				return fmt.Errorf("missing the following question keys: %v", missingKeys)
+			msgs, err := enc.BuildInputMessages(candidateQuestionRequest{
+				RepeatNumber:   i,
+				Resume:         c.QuestionText(useOriginalText),
+				Questions:      questionPrompts(repeatQuestions),
+				JobDescription: prompts.JobDescription,
+			})
+			if err != nil {
+				return jpf.Usage{}, err
 			}
-			return nil
+			usage = usage.Add(jpf.Usage{
+				InputTokens:     estimateMessagesTokens(msgs),
+				OutputTokens:    estimatedQuestionOutputTokensPerItem * len(repeatQuestions),
+				SuccessfulCalls: 1,
+			})
+		}
+	}
+	return usage, nil
+}
+
+// buildQuestionCandidateEncoder builds the question encoder from tmpl, or the built-in one.
+func buildQuestionCandidateEncoder(tmpl string) jpf.MessageEncoder[candidateQuestionRequest] {
+	if tmpl == "" {
+		tmpl = simpleCandidateQuestionTemplate
+	}
+	return jpf.NewTemplateMessageEncoder[candidateQuestionRequest](
+		"",
+		tmpl,
+	)
+}
+
+// ValidateQuestionTemplate checks that a custom question template renders.
+func ValidateQuestionTemplate(tmpl string, repeated bool, requireJobDescription bool) error {
+	example := func(repeatNumber int, jobDesc string) candidateQuestionRequest {
+		return candidateQuestionRequest{
+			RepeatNumber:   repeatNumber,
+			Resume:         "Example resume",
+			Questions:      map[string]string{"example": "What is this an example of?"},
+			JobDescription: jobDesc,
+		}
+	}
+	var err error
+	if repeated {
+		err = validateRepeatedPromptTemplate(tmpl, example(0, "Example job description"), example(1, "Example job description"))
+	} else {
+		err = validatePromptTemplate(tmpl, example(0, "Example job description"))
+	}
+	if err != nil {
+		return err
+	}
+	if requireJobDescription {
+		return validateJobDescriptionPromptTemplate(tmpl, example(0, "Example job description"), example(0, "Another job description"))
+	}
+	return nil
+}
+
+// questionsResponseSchema builds the JSON schema that a response to the questions must match.
+func questionsResponseSchema(questions map[string]SpecificQuestion) map[string]any {
+	items := make([]schemaProperty, 0, len(questions))
+	for _, k := range slices.Sorted(maps.Keys(questions)) {
+		answer := map[string]any{"type": "string"}
+		if len(questions[k].Choices) > 0 {
+			answer["enum"] = questions[k].Choices
+		}
+		items = append(items, schemaProperty{k, objectSchema(
+			schemaProperty{"reasoning", map[string]any{"type": "string"}},
+			schemaProperty{"answer", answer},
+		)})
+	}
+	return objectSchema(items...)
+}
+
+func buildQuestionCandidateMapFunc(modelBuilder ModelBuilder, logger *slog.Logger, tmpl string, questions map[string]SpecificQuestion) candidateQuestioner {
+	enc := buildQuestionCandidateEncoder(tmpl)
+	dec := newKeyedResponseDecoder[candidateQuestionRequest, string](
+		func(input candidateQuestionRequest) []string {
+			return slices.Sorted(maps.Keys(input.Questions))
 		},
+		"a JSON string",
 	)
+	dec = jpf.NewValidatingResponseDecoder(dec, func(_ candidateQuestionRequest, answers map[string]responseItem[string]) error {
+		return validateChoiceAnswers(questions, answers)
+	})
 	fed := jpf.NewRawMessageFeedbackGenerator()
-	model := modelBuilder.BuildCandidateReviewModel(logger)
+	model := modelBuilder.BuildCandidateReviewModel(logger, questionsResponseSchema(questions))
 	return jpf.NewFeedbackMapFunc(enc, dec, fed, model, jpf.UserRole, 10)
 }
 
@@ -125,14 +379,19 @@ const simpleCandidateQuestionTemplate = `You are an expert candidate reviewer. E
 
 For each question entry, produce:
 - "reasoning": your full internal reasoning and thought process leading to the answer  
-- "answer": an object with two keys, "reasoning" and "answer", where "reasoning" is a sentence or two reasoning about the question, and "answer" is a concise text answer to the question.
-
-Return a single JSON object where each key matches the exact question key. Do not return extra keys, and make sure to answer all questions.
+- "answer": a concise text answer to the question, as a string
 
+Return a single JSON object where each key matches the exact question key and each value is an object with the "reasoning" and "answer" keys. Do not return extra keys, and make sure to answer all questions.
+{{ if .JobDescription }}
+Answer each question in the context of the following job description:
+{{ .JobDescription }}
+{{ end }}
 Questions:
 {{ range $k, $v := .Questions }}
 - {{$k}}: {{$v}}
 {{ end }}
 
 Resume:
-{{ .Resume }}`
+{{ .Resume }}{{ if .RepeatNumber }}
+
+{{ .RepeatNumber }}{{ end }}`
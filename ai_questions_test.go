@@ -0,0 +1,119 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestMatchChoice(t *testing.T) {
+	choices := []string{"Junior", "Mid", "Senior"}
+	cases := []struct {
+		answer string
+		want   string
+		wantOK bool
+	}{
+		{"Senior", "Senior", true},
+		{"  senior\n", "Senior", true},
+		{"MID", "Mid", true},
+		{"Senior developer", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, ok := matchChoice(c.answer, choices)
+		if got != c.want || ok != c.wantOK {
+			t.Errorf("matchChoice(%q) = %q, %v, want %q, %v", c.answer, got, ok, c.want, c.wantOK)
+		}
+	}
+}
+
+func TestVoteOnChoices(t *testing.T) {
+	choices := []string{"Junior", "Mid", "Senior"}
+	repeat := func(answer string, reasoning string) map[string]responseItem[string] {
+		return map[string]responseItem[string]{"level": {Reasoning: reasoning, Answer: answer}}
+	}
+	cases := []struct {
+		name          string
+		repeats       []map[string]responseItem[string]
+		wantAnswer    string
+		wantReasoning string
+		wantVotes     map[string]int
+	}{
+		{"unanimous", []map[string]responseItem[string]{repeat("Mid", "a"), repeat("mid", "b")}, "Mid", "a", map[string]int{"Junior": 0, "Mid": 2, "Senior": 0}},
+		{"majority", []map[string]responseItem[string]{repeat("Junior", "a"), repeat("Senior", "b"), repeat("senior ", "c")}, "Senior", "b", map[string]int{"Junior": 1, "Mid": 0, "Senior": 2}},
+		{"tie goes to the earliest repeat", []map[string]responseItem[string]{repeat("Senior", "a"), repeat("Mid", "b")}, "Senior", "a", map[string]int{"Junior": 0, "Mid": 1, "Senior": 1}},
+		{"tie after unmatched answers", []map[string]responseItem[string]{repeat("Lead", "a"), repeat("Mid", "b"), repeat("Junior", "c")}, "Mid", "b", map[string]int{"Junior": 1, "Mid": 1, "Senior": 0}},
+		{"unmatched answers are not counted", []map[string]responseItem[string]{repeat("Lead", "a"), repeat("Senior", "b")}, "Senior", "b", map[string]int{"Junior": 0, "Mid": 0, "Senior": 1}},
+		{"no matched answers", []map[string]responseItem[string]{repeat("Lead", "a")}, "", "", map[string]int{"Junior": 0, "Mid": 0, "Senior": 0}},
+		{"missing key", []map[string]responseItem[string]{{}, {}}, "", "", map[string]int{"Junior": 0, "Mid": 0, "Senior": 0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := voteOnChoices(c.repeats, "level", choices)
+			if got.Answer != c.wantAnswer || got.Reasoning != c.wantReasoning || !maps.Equal(got.Votes, c.wantVotes) {
+				t.Errorf("voteOnChoices() = %q (%q, votes %v), want %q (%q, votes %v)", got.Answer, got.Reasoning, got.Votes, c.wantAnswer, c.wantReasoning, c.wantVotes)
+			}
+		})
+	}
+}
+
+func TestValidateQuestionTemplate(t *testing.T) {
+	const withoutRepeat = "{{ .Resume }} {{ range $k, $v := .Questions }}{{ $k }}: {{ $v }}{{ end }}"
+	cases := []struct {
+		name     string
+		tmpl     string
+		repeated bool
+		wantErr  bool
+	}{
+		{"built-in", simpleCandidateQuestionTemplate, true, false},
+		{"no repeat number, asked once", withoutRepeat, false, false},
+		{"no repeat number, repeated", withoutRepeat, true, true},
+		{"repeat number, repeated", withoutRepeat + " {{ .RepeatNumber }}", true, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := ValidateQuestionTemplate(c.tmpl, c.repeated, false); (err != nil) != c.wantErr {
+				t.Errorf("ValidateQuestionTemplate() error = %v, want error %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateChoiceAnswers(t *testing.T) {
+	questions := map[string]SpecificQuestion{
+		"level": {Question: "What level are they?", Choices: []string{"Junior", "Senior"}},
+		"name":  {Question: "What is their name?"},
+	}
+	answer := func(level string) map[string]responseItem[string] {
+		return map[string]responseItem[string]{
+			"level": {Reasoning: "r", Answer: level},
+			"name":  {Reasoning: "r", Answer: "Alice"},
+		}
+	}
+	cases := []struct {
+		name    string
+		answers map[string]responseItem[string]
+		wantErr string
+	}{
+		{"matching choice", answer("Senior"), ""},
+		{"matching choice in another case", answer(" senior "), ""},
+		{"free-text questions are not checked", map[string]responseItem[string]{"name": {Reasoning: "r", Answer: "anything"}}, ""},
+		{"no matching choice", answer("Lead"), `"level" must be answered with exactly one of ["Junior" "Senior"], got "Lead"`},
+		{"empty answer", answer(""), `"level" must be answered with exactly one of ["Junior" "Senior"], got ""`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateChoiceAnswers(questions, c.answers)
+			if c.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateChoiceAnswers() error = %v, want none", err)
+				}
+				return
+			}
+			// The error is sent back to the model as feedback, so it must say what was wrong and ask for the whole response again.
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) || !strings.Contains(err.Error(), "respond with the complete JSON object again") {
+				t.Errorf("validateChoiceAnswers() error = %v, want feedback containing %q", err, c.wantErr)
+			}
+		})
+	}
+}
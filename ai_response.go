@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JoshPattman/jpf"
+)
+
+// responseItem is a single item of a keyed model response.
+type responseItem[A any] struct {
+	Reasoning string `json:"reasoning"`
+	Answer    A      `json:"answer"`
+	// Evidence are optional quotes from the resume that support the answer.
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// newKeyedResponseDecoder builds a decoder for a JSON object with one item per key.
+func newKeyedResponseDecoder[T, A any](expectedKeys func(T) []string, answerDescription string) jpf.ResponseDecoder[T, map[string]responseItem[A]] {
+	dec := jpf.NewJsonResponseDecoder[T, map[string]json.RawMessage]()
+	return &keyedResponseDecoder[T, A]{
+		raw:               wrapJsonDecoder(dec),
+		expectedKeys:      expectedKeys,
+		answerDescription: answerDescription,
+	}
+}
+
+type keyedResponseDecoder[T, A any] struct {
+	raw               jpf.ResponseDecoder[T, map[string]json.RawMessage]
+	expectedKeys      func(T) []string
+	answerDescription string
+}
+
+func (dec *keyedResponseDecoder[T, A]) ParseResponseText(input T, response string) (map[string]responseItem[A], error) {
+	rawItems, err := dec.raw.ParseResponseText(input, response)
+	if err != nil {
+		return nil, err
+	}
+	expected := dec.expectedKeys(input)
+	problems := make([]string, 0)
+	missingKeys := make([]string, 0)
+	for _, k := range expected {
+		if _, ok := rawItems[k]; !ok {
+			missingKeys = append(missingKeys, k)
+		}
+	}
+	if len(missingKeys) > 0 {
+		problems = append(problems, fmt.Sprintf("missing the following keys: %v", missingKeys))
+	}
+	unknownKeys := make([]string, 0)
+	for k := range rawItems {
+		if !slices.Contains(expected, k) {
+			unknownKeys = append(unknownKeys, k)
+		}
+	}
+	if len(unknownKeys) > 0 {
+		slices.Sort(unknownKeys)
+		problems = append(problems, fmt.Sprintf("the following keys were not asked for and must be removed: %v", unknownKeys))
+	}
+	result := make(map[string]responseItem[A], len(rawItems))
+	for _, k := range expected {
+		raw, ok := rawItems[k]
+		if !ok {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			problems = append(problems, fmt.Sprintf("%q must be an object with \"reasoning\" and \"answer\" keys, got %s", k, truncateForFeedback(raw)))
+			continue
+		}
+		item := responseItem[A]{}
+		if rawReasoning, ok := fields["reasoning"]; !ok || json.Unmarshal(rawReasoning, &item.Reasoning) != nil || strings.TrimSpace(item.Reasoning) == "" {
+			problems = append(problems, fmt.Sprintf("%q must have a non-empty string for \"reasoning\"", k))
+		}
+		// A null answer would decode into the zero value, such as false or an empty string, so it is rejected like a missing one.
+		if rawAnswer, ok := fields["answer"]; !ok || string(bytes.TrimSpace(rawAnswer)) == "null" {
+			problems = append(problems, fmt.Sprintf("%q is missing \"answer\", which must be %s", k, dec.answerDescription))
+		} else if err := json.Unmarshal(rawAnswer, &item.Answer); err != nil {
+			problems = append(problems, fmt.Sprintf("%q has an invalid \"answer\", it must be %s but got %s", k, dec.answerDescription, truncateForFeedback(rawAnswer)))
+		}
+		if rawEvidence, ok := fields["evidence"]; ok && string(rawEvidence) != "null" {
+			if err := json.Unmarshal(rawEvidence, &item.Evidence); err != nil {
+				problems = append(problems, fmt.Sprintf("%q has invalid \"evidence\", it must be a list of strings but got %s", k, truncateForFeedback(rawEvidence)))
+			}
+		}
+		result[k] = item
+	}
+	if err := problemsError(problems); err != nil {
+		return nil, errors.Join(err, jpf.ErrInvalidResponse)
+	}
+	return result, nil
+}
+
+// truncateForFeedback shortens a raw JSON value so it can be quoted back to the model.
+func truncateForFeedback(raw json.RawMessage) string {
+	const maxLen = 80
+	s := string(raw)
+	if len(s) <= maxLen {
+		return s
+	}
+	end := maxLen
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "..."
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestKeyedResponseDecoder(t *testing.T) {
+	dec := newKeyedResponseDecoder[[]string, bool](func(keys []string) []string { return keys }, "a JSON boolean")
+	cases := []struct {
+		name    string
+		keys    []string
+		resp    string
+		wantErr string
+	}{
+		{"valid", []string{"a"}, `{"a": {"reasoning": "r", "answer": true}}`, ""},
+		{"valid with evidence", []string{"a"}, `{"a": {"reasoning": "r", "evidence": ["x"], "answer": true}}`, ""},
+		{"missing key", []string{"a", "b"}, `{"a": {"reasoning": "r", "answer": true}}`, "missing the following keys: [b]"},
+		{"unknown key", []string{"a"}, `{"a": {"reasoning": "r", "answer": true}, "c": {}}`, "were not asked for"},
+		{"empty reasoning", []string{"a"}, `{"a": {"reasoning": " ", "answer": true}}`, "non-empty string"},
+		{"missing answer", []string{"a"}, `{"a": {"reasoning": "r"}}`, "missing \"answer\""},
+		{"null answer", []string{"a"}, `{"a": {"reasoning": "r", "answer": null}}`, "missing \"answer\""},
+		{"mistyped answer", []string{"a"}, `{"a": {"reasoning": "r", "answer": "yes"}}`, "invalid \"answer\""},
+		{"mistyped evidence", []string{"a"}, `{"a": {"reasoning": "r", "evidence": "x", "answer": true}}`, "invalid \"evidence\""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := dec.ParseResponseText(c.keys, c.resp)
+			if c.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+				t.Fatalf("error = %v, want it to contain %q", err, c.wantErr)
+			}
+		})
+	}
+}
+
+func TestTruncateForFeedback(t *testing.T) {
+	short := `"short"`
+	if got := truncateForFeedback(json.RawMessage(short)); got != short {
+		t.Errorf("truncateForFeedback(%s) = %s, want it unchanged", short, got)
+	}
+	// Each é is two bytes, so a cut at 80 bytes would land in the middle of one.
+	long := `"` + strings.Repeat("é", 60) + `"`
+	got := truncateForFeedback(json.RawMessage(long))
+	if !utf8.ValidString(got) {
+		t.Errorf("truncateForFeedback split a character: %q", got)
+	}
+	if !strings.HasSuffix(got, "...") || len(got) > 83 {
+		t.Errorf("truncateForFeedback(%s) = %s, want at most 80 bytes followed by ...", long, got)
+	}
+}
@@ -2,9 +2,10 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"maps"
 	"math"
+	"slices"
 
 	"github.com/JoshPattman/jpf"
 )
@@ -12,15 +13,24 @@ import (
 // CandidateQuestionResult represents the result of a single checklist question for a candidate.
 type CandidateQuestionResult struct {
 	probability float64
+	// numeric is true for number and rating items.
+	numeric   bool
+	value     float64
+	variance  float64
+	reasoning string
+	evidence  []string
 }
 
 // IsTrue returns true if the candidate is likely to satisfy the checklist item.
 func (c CandidateQuestionResult) IsTrue() bool {
-	return c.probability > 0.5
+	return !c.numeric && c.probability > 0.5
 }
 
 // Inconsistency returns a measure of how inconsistent the model's answers were for this checklist item.
 func (c CandidateQuestionResult) Inconsistency() float64 {
+	if c.numeric {
+		return c.variance
+	}
 	return min(c.probability, 1-c.probability) * 2
 }
 
@@ -29,29 +39,56 @@ func (c CandidateQuestionResult) Probability() float64 {
 	return c.probability
 }
 
+// IsNumeric returns true if the result is of a number or rating item.
+func (c CandidateQuestionResult) IsNumeric() bool {
+	return c.numeric
+}
+
+// Value returns the aggregated answer of a number or rating item, or 0 for boolean items.
+func (c CandidateQuestionResult) Value() float64 {
+	return c.value
+}
+
+// Reasoning returns the model's reasoning from a repeat that agreed with the answer.
+func (c CandidateQuestionResult) Reasoning() string {
+	return c.reasoning
+}
+
+// Evidence returns the verified resume quotes that support the answer.
+func (c CandidateQuestionResult) Evidence() []string {
+	return c.evidence
+}
+
 // Review the candidates' resumes against the checklist using the provided model builder and logger.
-func ReviewCandidates(logger *slog.Logger, modelBuilder ModelBuilder, checklist map[string]string, resumes []string, numRepeats int) ([]map[string]CandidateQuestionResult, error) {
-	if len(resumes) == 0 {
+func ReviewCandidates(logger *slog.Logger, modelBuilder ModelBuilder, prompts ViewPrompts, checklist map[string]ChecklistQuestion, candidates []Candidate, numRepeats int, progress *ViewProgress) ([]map[string]CandidateQuestionResult, error) {
+	if len(candidates) == 0 {
 		logger.Info("No resumes provided for checklist, skipping")
 		return []map[string]CandidateQuestionResult{}, nil
 	}
 	if len(checklist) == 0 {
 		logger.Info("No questions provided for checklist, skipping")
-		return make([]map[string]CandidateQuestionResult, len(resumes)), nil
+		results := make([]map[string]CandidateQuestionResult, len(candidates))
+		for i := range results {
+			results[i] = map[string]CandidateQuestionResult{}
+		}
+		return results, nil
 	}
 	task := &candidateReviewTask{
 		modelBuilder: modelBuilder,
 		logger:       logger,
+		template:     prompts.ReviewTemplate,
+		jobDesc:      prompts.JobDescription,
 		checklist:    checklist,
-		resumes:      resumes,
+		candidates:   candidates,
 		repeats:      numRepeats,
+		progress:     progress,
 	}
 	logger.Info(
 		"Reviewing resumes",
-		"num_resumes", len(resumes),
+		"num_resumes", len(candidates),
 		"num_checklist", len(checklist),
 		"num_repeats", task.repeats,
-		"estimated_llm_calls", len(resumes)*task.repeats,
+		"estimated_llm_calls", len(candidates)*task.repeats,
 	)
 	return task.execute()
 }
@@ -59,27 +96,34 @@ func ReviewCandidates(logger *slog.Logger, modelBuilder ModelBuilder, checklist
 type candidateReviewTask struct {
 	modelBuilder ModelBuilder
 	logger       *slog.Logger
-	checklist    map[string]string
-	resumes      []string
+	template     string
+	jobDesc      string
+	checklist    map[string]ChecklistQuestion
+	candidates   []Candidate
 	repeats      int
+	progress     *ViewProgress
 }
 
 func (reviewer *candidateReviewTask) execute() ([]map[string]CandidateQuestionResult, error) {
-	reviewer.logger.Info("Beginning candidate reviews", "num_candidates", len(reviewer.resumes))
+	reviewer.logger.Info("Beginning candidate reviews", "num_candidates", len(reviewer.candidates))
 	return ParMapRange(
-		len(reviewer.resumes),
+		len(reviewer.candidates),
 		func(i int) (map[string]CandidateQuestionResult, error) {
-			candidateLogger := reviewer.logger.With("resume", i)
-			candidateLogger.Info("Begun candidate review")
-			res, err := reviewer.reviewSingleCandidate(i)
+			candidateLogger := reviewer.logger.With("candidate_id", reviewer.candidates[i].ID)
+			candidateLogger.Info("Begun candidate review", "file", reviewer.candidates[i].Name)
+			res, err := reviewer.reviewSingleCandidate(candidateLogger, i)
 			if err != nil {
 				candidateLogger.Error("Failed to review candidate", "err", err)
 			} else {
-				inconsistency := 0.0
+				// Variances of number and rating items are on a different scale, so only boolean items count towards the overall inconsistency.
+				inconsistency, numBoolean := 0.0, 0
 				for _, v := range res {
-					inconsistency += v.Inconsistency()
+					if !v.IsNumeric() {
+						inconsistency += v.Inconsistency()
+						numBoolean++
+					}
 				}
-				inconsistency /= float64(len(res))
+				inconsistency /= float64(max(numBoolean, 1))
 				candidateLogger.Debug("Completed candidate review", "result", res)
 				candidateLogger.Info("Completed candidate review", "inconsistency", math.Round(inconsistency*100)/100)
 			}
@@ -88,12 +132,12 @@ func (reviewer *candidateReviewTask) execute() ([]map[string]CandidateQuestionRe
 	)
 }
 
-func (reviewer *candidateReviewTask) reviewSingleCandidate(candidateIndex int) (map[string]CandidateQuestionResult, error) {
+func (reviewer *candidateReviewTask) reviewSingleCandidate(logger *slog.Logger, candidateIndex int) (map[string]CandidateQuestionResult, error) {
 	// In parallell, repeat the review several times.
 	resultsPerRepeat, err := ParMapRange(
 		reviewer.repeats,
-		func(i int) (map[string]bool, error) {
-			repLogger := reviewer.logger.With("repeat", i)
+		func(i int) (map[string]responseItem[checklistAnswer], error) {
+			repLogger := logger.With("repeat", i)
 			return reviewer.reviewCandidateOnce(repLogger, candidateIndex, i)
 		},
 	)
@@ -101,84 +145,195 @@ func (reviewer *candidateReviewTask) reviewSingleCandidate(candidateIndex int) (
 		return nil, err
 	}
 	// Aggregate the results.
-	probs := make(map[string]CandidateQuestionResult)
+	probs := make(map[string]CandidateQuestionResult, len(reviewer.checklist))
+	for k, q := range reviewer.checklist {
+		if q.Type.IsNumeric() {
+			probs[k] = aggregateNumericResults(resultsPerRepeat, k, q.Aggregate)
+		} else {
+			probs[k] = aggregateBooleanResults(resultsPerRepeat, k)
+		}
+	}
+	return probs, nil
+}
+
+// aggregateBooleanResults combines the repeats of a yes/no item into a probability.
+func aggregateBooleanResults(resultsPerRepeat []map[string]responseItem[checklistAnswer], key string) CandidateQuestionResult {
+	trueCount := 0
 	for _, results := range resultsPerRepeat {
-		for k, v := range results {
-			var delta float64
-			if v {
-				delta = 1
-			}
-			probs[k] = CandidateQuestionResult{
-				probs[k].probability + delta,
-			}
+		if results[key].Answer.Boolean {
+			trueCount++
 		}
 	}
-	for k, v := range probs {
-		probs[k] = CandidateQuestionResult{
-			v.probability / float64(reviewer.repeats),
+	probability := float64(trueCount) / float64(len(resultsPerRepeat))
+	// Keep the reasoning and evidence of the first repeat that agreed with the overall answer, so they explain that answer.
+	var explanation responseItem[checklistAnswer]
+	for _, results := range resultsPerRepeat {
+		if results[key].Answer.Boolean == (probability > 0.5) {
+			explanation = results[key]
+			break
 		}
 	}
-	return probs, nil
+	return CandidateQuestionResult{
+		probability: probability,
+		reasoning:   explanation.Reasoning,
+		evidence:    explanation.Evidence,
+	}
 }
 
-type candidateReviewRequest struct {
-	RepeatNumber int
-	Checklist    map[string]string
-	Resume       string
+// aggregateNumericResults combines the repeats of a numeric item into a value and variance.
+func aggregateNumericResults(resultsPerRepeat []map[string]responseItem[checklistAnswer], key string, aggregate ChecklistAggregate) CandidateQuestionResult {
+	values := make([]float64, len(resultsPerRepeat))
+	for i, results := range resultsPerRepeat {
+		values[i] = results[key].Answer.Number
+	}
+	value, variance := aggregateValues(values, aggregate)
+	// Keep the reasoning and evidence of the repeat whose answer was closest to the overall answer, so they explain that answer.
+	var explanation responseItem[checklistAnswer]
+	closest := math.Inf(1)
+	for _, results := range resultsPerRepeat {
+		if d := math.Abs(results[key].Answer.Number - value); d < closest {
+			closest = d
+			explanation = results[key]
+		}
+	}
+	return CandidateQuestionResult{
+		numeric:   true,
+		value:     value,
+		variance:  variance,
+		reasoning: explanation.Reasoning,
+		evidence:  explanation.Evidence,
+	}
 }
 
-type candidateReviewResponse map[string]checklistItemResponse
-
-type checklistItemResponse struct {
-	Reasoning string `json:"reasoning"`
-	Answer    bool   `json:"answer"`
+type candidateReviewRequest struct {
+	RepeatNumber   int
+	Checklist      map[string]string
+	Resume         string
+	JobDescription string
 }
 
-type candidateReviewer jpf.MapFunc[candidateReviewRequest, candidateReviewResponse]
+type candidateReviewer jpf.MapFunc[candidateReviewRequest, map[string]responseItem[checklistAnswer]]
 
-func (reviewer *candidateReviewTask) reviewCandidateOnce(logger *slog.Logger, candidateIndex int, repeatNumber int) (map[string]bool, error) {
-	mf := buildReviewCandidateReviewMapFunc(reviewer.modelBuilder, logger)
+func (reviewer *candidateReviewTask) reviewCandidateOnce(logger *slog.Logger, candidateIndex int, repeatNumber int) (map[string]responseItem[checklistAnswer], error) {
+	mf := buildReviewCandidateReviewMapFunc(reviewer.modelBuilder, logger, reviewer.template, reviewer.checklist)
 	inputData := candidateReviewRequest{
-		RepeatNumber: repeatNumber,
-		Checklist:    reviewer.checklist,
-		Resume:       reviewer.resumes[candidateIndex],
+		RepeatNumber:   repeatNumber,
+		Checklist:      checklistPrompts(reviewer.checklist),
+		Resume:         reviewer.candidates[candidateIndex].ReviewedText,
+		JobDescription: reviewer.jobDesc,
 	}
 	result, _, err := mf.Call(context.Background(), inputData)
+	reviewer.progress.Done()
 	if err != nil {
 		return nil, err
 	}
-	answers := make(map[string]bool)
-	for key, resp := range result {
-		answers[key] = resp.Answer
+	return result, nil
+}
+
+// estimatedReviewOutputTokensPerItem is a rough guess of the output tokens of a checklist item.
+const estimatedReviewOutputTokensPerItem = 80
+
+// EstimateReviewUsage estimates the usage of reviewing every candidate against the checklist.
+func EstimateReviewUsage(prompts ViewPrompts, checklist map[string]ChecklistQuestion, candidates []Candidate, numRepeats int) (jpf.Usage, error) {
+	usage := jpf.Usage{}
+	if len(checklist) == 0 {
+		return usage, nil
+	}
+	enc := buildReviewCandidateEncoder(prompts.ReviewTemplate)
+	for _, c := range candidates {
+		for i := range numRepeats {
+			msgs, err := enc.BuildInputMessages(candidateReviewRequest{
+				RepeatNumber:   i,
+				Checklist:      checklistPrompts(checklist),
+				Resume:         c.ReviewedText,
+				JobDescription: prompts.JobDescription,
+			})
+			if err != nil {
+				return jpf.Usage{}, err
+			}
+			usage = usage.Add(jpf.Usage{
+				InputTokens:     estimateMessagesTokens(msgs),
+				OutputTokens:    estimatedReviewOutputTokensPerItem * len(checklist),
+				SuccessfulCalls: 1,
+			})
+		}
 	}
-	return answers, nil
+	return usage, nil
 }
 
-// Build a mapfunc (a typed LLM call with retry logic) for reviewing a candidate.
-func buildReviewCandidateReviewMapFunc(modelBuilder ModelBuilder, logger *slog.Logger) candidateReviewer {
-	enc := jpf.NewTemplateMessageEncoder[candidateReviewRequest](
+// buildReviewCandidateEncoder builds the review encoder from tmpl, or the built-in one.
+func buildReviewCandidateEncoder(tmpl string) jpf.MessageEncoder[candidateReviewRequest] {
+	if tmpl == "" {
+		tmpl = simpleCandidateReviewTemplate
+	}
+	return jpf.NewTemplateMessageEncoder[candidateReviewRequest](
 		"",
-		simpleCandidateReviewTemplate,
+		tmpl,
 	)
-	dec := jpf.NewJsonResponseDecoder[candidateReviewRequest, candidateReviewResponse]()
-	dec = wrapJsonDecoder(dec)
-	dec = jpf.NewValidatingResponseDecoder(
-		dec,
-		func(input candidateReviewRequest, response candidateReviewResponse) error {
-			missingKeys := make([]string, 0)
-			for k := range input.Checklist {
-				if _, ok := response[k]; !ok {
-					missingKeys = append(missingKeys, k)
-				}
-			}
-			if len(missingKeys) > 0 {
-				return fmt.Errorf("missing the following question keys: %v", missingKeys)
-			}
-			return nil
+}
+
+// ValidateReviewTemplate checks that a custom review template renders.
+func ValidateReviewTemplate(tmpl string, requireJobDescription bool) error {
+	example := func(repeatNumber int, jobDesc string) candidateReviewRequest {
+		return candidateReviewRequest{
+			RepeatNumber:   repeatNumber,
+			Checklist:      map[string]string{"example": "Is this an example?"},
+			Resume:         "Example resume",
+			JobDescription: jobDesc,
+		}
+	}
+	if err := validateRepeatedPromptTemplate(tmpl, example(0, "Example job description"), example(1, "Example job description")); err != nil {
+		return err
+	}
+	if requireJobDescription {
+		return validateJobDescriptionPromptTemplate(tmpl, example(0, "Example job description"), example(0, "Another job description"))
+	}
+	return nil
+}
+
+// checklistPrompts maps each checklist key to the question shown to the model.
+func checklistPrompts(checklist map[string]ChecklistQuestion) map[string]string {
+	prompts := make(map[string]string, len(checklist))
+	for k, q := range checklist {
+		prompts[k] = q.Prompt()
+	}
+	return prompts
+}
+
+// reviewResponseSchema builds the JSON schema of a review response.
+func reviewResponseSchema(checklist map[string]ChecklistQuestion) map[string]any {
+	items := make([]schemaProperty, 0, len(checklist))
+	for _, k := range slices.Sorted(maps.Keys(checklist)) {
+		answerType := "boolean"
+		switch checklist[k].Type {
+		case ChecklistNumber:
+			answerType = "number"
+		case ChecklistRating:
+			answerType = "integer"
+		}
+		items = append(items, schemaProperty{k, objectSchema(
+			schemaProperty{"reasoning", map[string]any{"type": "string"}},
+			schemaProperty{"evidence", map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+			schemaProperty{"answer", map[string]any{"type": answerType}},
+		)})
+	}
+	return objectSchema(items...)
+}
+
+// Build a mapfunc (a typed LLM call with retry logic) for reviewing a candidate.
+func buildReviewCandidateReviewMapFunc(modelBuilder ModelBuilder, logger *slog.Logger, tmpl string, checklist map[string]ChecklistQuestion) candidateReviewer {
+	enc := buildReviewCandidateEncoder(tmpl)
+	dec := newKeyedResponseDecoder[candidateReviewRequest, checklistAnswer](
+		func(input candidateReviewRequest) []string {
+			return slices.Sorted(maps.Keys(input.Checklist))
 		},
+		"a JSON boolean (true or false), or a number if the checklist item asks for one",
 	)
+	dec = jpf.NewValidatingResponseDecoder(dec, func(input candidateReviewRequest, answers map[string]responseItem[checklistAnswer]) error {
+		return validateChecklistAnswers(checklist, input.Resume, answers)
+	})
 	fed := jpf.NewRawMessageFeedbackGenerator()
-	model := modelBuilder.BuildCandidateReviewModel(logger)
+	model := modelBuilder.BuildCandidateReviewModel(logger, reviewResponseSchema(checklist))
 	return jpf.NewFeedbackMapFunc(enc, dec, fed, model, jpf.UserRole, 10)
 }
 
@@ -186,10 +341,14 @@ const simpleCandidateReviewTemplate = `You are an expert candidate reviewer. Exa
 
 For each checklist entry, produce:
 - "reasoning": your full internal reasoning and thought process leading to the answer  
+- "evidence": a list of short quotes, copied exactly from the resume, that support the answer (an empty list if nothing in the resume is relevant)
 - "answer": true or false
 
 Return a single JSON object where each key matches the exact checklist key.
-
+{{ if .JobDescription }}
+Answer each checklist item in the context of the following job description:
+{{ .JobDescription }}
+{{ end }}
 Checklist:
 {{ range $k, $v := .Checklist }}
 - {{$k}}: {{$v}}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReviewTemplate(t *testing.T) {
+	const withRepeat = "{{ .Resume }} {{ range $k, $v := .Checklist }}{{ $k }}{{ end }} (attempt {{ .RepeatNumber }})"
+	cases := []struct {
+		name                  string
+		tmpl                  string
+		requireJobDescription bool
+		wantErr               string
+	}{
+		{"built-in", simpleCandidateReviewTemplate, false, ""},
+		{"repeat number", withRepeat, false, ""},
+		{"no repeat number", "{{ .Resume }} {{ range $k, $v := .Checklist }}{{ $k }}{{ end }}", false, "RepeatNumber"},
+		{"repeat number in a comment", "{{/* .RepeatNumber */}}{{ .Resume }}", false, "RepeatNumber"},
+		{"unknown field", "{{ .Resume }} {{ .RepeatNumber }} {{ .Candidate }}", false, "Candidate"},
+		{"syntax error", "{{ .Resume ", false, "unclosed action"},
+		{"built-in with job description", simpleCandidateReviewTemplate, true, ""},
+		{"job description", withRepeat + " {{ .JobDescription }}", true, ""},
+		{"no job description", withRepeat, true, "JobDescription"},
+		{"job description only tested", withRepeat + "{{ if .JobDescription }} for a job{{ end }}", true, "JobDescription"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateReviewTemplate(c.tmpl, c.requireJobDescription)
+			if c.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+				t.Fatalf("error = %v, want it to contain %q", err, c.wantErr)
+			}
+		})
+	}
+}
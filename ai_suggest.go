@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/JoshPattman/jpf"
+)
+
+// SuggestView asks the model to draft a view for the given job description.
+func SuggestView(logger *slog.Logger, modelBuilder ModelBuilder, jobDescription string) (ConfigView, error) {
+	if strings.TrimSpace(jobDescription) == "" {
+		return ConfigView{}, errors.New("job description is empty")
+	}
+	logger.Info("Suggesting view from job description", "job_description_length", len(jobDescription))
+	mf := buildSuggestViewMapFunc(modelBuilder, logger)
+	result, _, err := mf.Call(context.Background(), suggestViewRequest{JobDescription: jobDescription})
+	if err != nil {
+		return ConfigView{}, err
+	}
+	view := ConfigView{
+		PrettyName:        result.PrettyName,
+		ScoreChecklist:    make(map[string]ConfigScoreChecklistItem),
+		SpecificQuestions: make(map[string]ConfigSpecificQuestion),
+	}
+	for _, item := range result.ScoreChecklist {
+		view.ScoreChecklist[item.Key] = ConfigScoreChecklistItem{
+			Question:  item.Question,
+			Weight:    item.Weight,
+			Important: item.Important,
+		}
+	}
+	for _, q := range result.SpecificQuestions {
+		view.SpecificQuestions[q.Key] = ConfigSpecificQuestion{Question: q.Question}
+	}
+	return view, nil
+}
+
+type suggestViewRequest struct {
+	JobDescription string
+}
+
+// suggestViewResponse uses lists rather than maps so it has a strict JSON schema.
+type suggestViewResponse struct {
+	PrettyName        string                      `json:"pretty_name"`
+	ScoreChecklist    []suggestedChecklistItem    `json:"score_checklist"`
+	SpecificQuestions []suggestedSpecificQuestion `json:"specific_questions"`
+}
+
+type suggestedChecklistItem struct {
+	Key       string  `json:"key"`
+	Question  string  `json:"question"`
+	Weight    float64 `json:"weight"`
+	Important bool    `json:"important"`
+}
+
+type suggestedSpecificQuestion struct {
+	Key      string `json:"key"`
+	Question string `json:"question"`
+}
+
+type viewSuggester jpf.MapFunc[suggestViewRequest, suggestViewResponse]
+
+var suggestedKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateSuggestedView checks that the suggested view could be loaded as config.
+func validateSuggestedView(_ suggestViewRequest, response suggestViewResponse) error {
+	problems := make([]string, 0)
+	if strings.TrimSpace(response.PrettyName) == "" {
+		problems = append(problems, "pretty_name must not be empty")
+	}
+	if len(response.ScoreChecklist) == 0 {
+		problems = append(problems, "score_checklist must contain at least one item")
+	}
+	seen := make(map[string]bool)
+	checkKey := func(list string, i int, key string) {
+		if !suggestedKeyPattern.MatchString(key) {
+			problems = append(problems, fmt.Sprintf("%s[%d].key %q must be snake_case (lowercase letters, digits and underscores, starting with a letter)", list, i, key))
+		} else if seen[key] {
+			problems = append(problems, fmt.Sprintf("%s[%d].key %q is used more than once, keys must be unique across the checklist and questions", list, i, key))
+		}
+		seen[key] = true
+	}
+	for i, item := range response.ScoreChecklist {
+		checkKey("score_checklist", i, item.Key)
+		if strings.TrimSpace(item.Question) == "" {
+			problems = append(problems, fmt.Sprintf("score_checklist[%d].question must not be empty", i))
+		}
+		if math.IsNaN(item.Weight) || item.Weight <= 0 || item.Weight > 10 {
+			problems = append(problems, fmt.Sprintf("score_checklist[%d].weight must be between 0 and 10 (exclusive of 0)", i))
+		}
+	}
+	for i, q := range response.SpecificQuestions {
+		checkKey("specific_questions", i, q.Key)
+		if strings.TrimSpace(q.Question) == "" {
+			problems = append(problems, fmt.Sprintf("specific_questions[%d].question must not be empty", i))
+		}
+	}
+	return problemsError(problems)
+}
+
+// suggestViewResponseSchema is the JSON schema that a suggested view must match.
+func suggestViewResponseSchema() map[string]any {
+	str := map[string]any{"type": "string"}
+	return objectSchema(
+		schemaProperty{"pretty_name", str},
+		schemaProperty{"score_checklist", map[string]any{
+			"type": "array",
+			"items": objectSchema(
+				schemaProperty{"key", str},
+				schemaProperty{"question", str},
+				schemaProperty{"weight", map[string]any{"type": "number"}},
+				schemaProperty{"important", map[string]any{"type": "boolean"}},
+			),
+		}},
+		schemaProperty{"specific_questions", map[string]any{
+			"type": "array",
+			"items": objectSchema(
+				schemaProperty{"key", str},
+				schemaProperty{"question", str},
+			),
+		}},
+	)
+}
+
+func buildSuggestViewMapFunc(modelBuilder ModelBuilder, logger *slog.Logger) viewSuggester {
+	enc := jpf.NewTemplateMessageEncoder[suggestViewRequest](
+		"",
+		simpleSuggestViewTemplate,
+	)
+	dec := jpf.NewJsonResponseDecoder[suggestViewRequest, suggestViewResponse]()
+	dec = wrapJsonDecoder(dec)
+	dec = jpf.NewValidatingResponseDecoder(dec, validateSuggestedView)
+	fed := jpf.NewRawMessageFeedbackGenerator()
+	model := modelBuilder.BuildCandidateReviewModel(logger, suggestViewResponseSchema())
+	return jpf.NewFeedbackMapFunc(enc, dec, fed, model, jpf.UserRole, 10)
+}
+
+const simpleSuggestViewTemplate = `You are an expert technical recruiter. Read the job description carefully and draft a screening checklist that a reviewer could answer from a candidate's resume alone.
+
+Produce a single JSON object with the following keys:
+- "pretty_name": a short human-readable name for the role
+- "score_checklist": a list of 5 to 15 yes/no checklist items, each an object with:
+    - "key": a short unique snake_case identifier
+    - "question": a yes/no question about the candidate that can be answered from their resume
+    - "weight": how much the item matters, 1 for a normal item, up to 3 for a core requirement, and below 1 for a nice-to-have
+    - "important": true only if the item is a hard requirement that should rule out candidates who fail it
+- "specific_questions": a list of 2 to 5 free-text questions that would help a recruiter compare candidates, each an object with:
+    - "key": a short unique snake_case identifier, different from every checklist key
+    - "question": the question, including how the answer should be formatted
+
+Do not include questions about protected characteristics such as age, gender, nationality, or marital status.
+
+Job description:
+{{ .JobDescription }}`
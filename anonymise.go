@@ -0,0 +1,227 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// AnonymisedItem is something that was removed from a resume by AnonymiseResume.
+type AnonymisedItem struct {
+	// Category is what kind of attribute was removed, such as name or pronoun.
+	Category string
+	Removed  string
+	Count    int
+}
+
+// labelValuePattern captures the value after a label such as "Gender:".
+const labelValuePattern = `(\p{L}[\p{L}.'\-]*(?:[ \t]+\p{L}[\p{L}.'\-]*)*?)(?:[ \t]*$|[ \t]*[^\p{L}.'\- \t]|(?:[ \t]+\p{L}[\p{L}\-]*){1,3}[ \t]*:)`
+
+var (
+	nameLabelPattern = regexp.MustCompile(`(?im)^[ \t]*(?:full\s+)?name\s*[:\-]\s*([^\n]+)`)
+	namePartPattern  = regexp.MustCompile(`^\p{Lu}[\p{L}\p{M}'\-]*\.?$`)
+	// labelledNamePattern is looser than looksLikeName, as labelled names may lack capitals.
+	labelledNamePattern = regexp.MustCompile(`^[\p{L}\p{M}'.\-]+(?:\s+[\p{L}\p{M}'.\-]+){0,3}$`)
+	titlePattern        = regexp.MustCompile(`\b(?:Mr|Mrs|Ms|Miss|Mx)\b\.?\s*`)
+	pronounPattern      = regexp.MustCompile(`(?i)\b(?:he|she|him|his|her|hers|himself|herself)\b`)
+	genderPattern       = regexp.MustCompile(`(?im)\b(?:gender|sex)\s*[:\-]\s*` + labelValuePattern)
+	// Photos only leave a caption or label behind, such as "Photo:" or "[Image]" on a line of its own.
+	photoPattern = regexp.MustCompile(`(?im)^[ \t]*\[?(?:photo|photograph|picture|headshot|portrait|image)\]?[ \t]*(?::[^\n]*)?$`)
+	agePatterns  = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bage[d]?\s*[:\-]?\s*(\d{1,2})\b`),
+		regexp.MustCompile(`(?i)\b(\d{1,2}\s*(?:years?\s+old|yrs?\s+old|y/o))`),
+	}
+	// Years are only removed where they date a qualification.
+	graduationPhrasePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(?:graduat\w*|class\s+of)\b[^\n\d]{0,20}((?:19|20)\d{2})\b`),
+		regexp.MustCompile(`(?i)\b((?:19|20)\d{2})\s+graduate\b`),
+	}
+	qualificationLinePattern = regexp.MustCompile(`(?im)^[^\n]*\b(?:bachelor\w*|master'?s\s+(?:degree|of)|degree|diploma|b\.?sc|m\.?sc|[bm]a\s+\(?hons|mba|ph\.?d|gcse\w*|a-levels?)(?:\b|\.)[^\n]*`)
+	graduationYearPattern    = regexp.MustCompile(`\b(?:19|20)\d{2}\b`)
+	nationalityPattern       = regexp.MustCompile(`(?im)\b(?:nationality|citizenship|place\s+of\s+birth|country\s+of\s+birth)\s*[:\-]\s*` + labelValuePattern)
+	maritalPatterns          = []*regexp.Regexp{
+		regexp.MustCompile(`(?im)\b(?:marital|civil)\s+status\s*[:\-]\s*` + labelValuePattern),
+		regexp.MustCompile(`(?i)\b(married|divorced|widowed)\b`),
+	}
+)
+
+// neutralPronouns maps gendered pronouns to their gender neutral replacement.
+var neutralPronouns = map[string]string{
+	"he":      "they",
+	"she":     "they",
+	"him":     "them",
+	"his":     "their",
+	"her":     "their",
+	"hers":    "theirs",
+	"himself": "themselves",
+	"herself": "themselves",
+}
+
+// AnonymiseResume removes identifying attributes from the resume, returning what was removed.
+func AnonymiseResume(text string) (string, []AnonymisedItem) {
+	items := make([]AnonymisedItem, 0)
+	record := func(category string, removed string) {
+		removed = strings.TrimSpace(removed)
+		i := slices.IndexFunc(items, func(item AnonymisedItem) bool { return item.Category == category && item.Removed == removed })
+		if i == -1 {
+			items = append(items, AnonymisedItem{Category: category, Removed: removed, Count: 1})
+		} else {
+			items[i].Count++
+		}
+	}
+	replaceWith := func(category string, replacement string) func(string) string {
+		return func(match string) string {
+			record(category, match)
+			return replacement
+		}
+	}
+
+	// Names are replaced first, as later patterns (such as titles) could otherwise split them up.
+	// The candidate is often referred to by their first or last name alone, so the parts of a labelled name are replaced too,
+	// unless they are also ordinary words, which would remove skills or job titles from the text.
+	labelled, firstLine := findCandidateNames(text)
+	parts := make([]string, 0)
+	for _, name := range labelled {
+		for _, part := range strings.Fields(name) {
+			part = strings.TrimSuffix(part, ".")
+			if len([]rune(part)) > 1 && !slices.Contains(parts, part) && !isCommonWord(part, text) {
+				parts = append(parts, part)
+			}
+		}
+	}
+	for _, name := range append(labelled, firstLine...) {
+		text = replaceWord(text, name, replaceWith("name", "[CANDIDATE]"))
+	}
+	for _, part := range parts {
+		text = replaceWord(text, part, replaceWith("name", "[CANDIDATE]"))
+	}
+	text = titlePattern.ReplaceAllStringFunc(text, replaceWith("title", ""))
+	text = pronounPattern.ReplaceAllStringFunc(text, func(match string) string {
+		record("pronoun", strings.ToLower(match))
+		return matchCase(neutralPronouns[strings.ToLower(match)], match)
+	})
+	text = replaceSubmatch(text, genderPattern, 1, replaceWith("gender", "[REMOVED]"))
+	text = photoPattern.ReplaceAllStringFunc(text, replaceWith("photo", ""))
+	text = replaceSubmatch(text, dateOfBirthPattern, 1, replaceWith("age", "[REMOVED]"))
+	for _, pattern := range agePatterns {
+		text = replaceSubmatch(text, pattern, 1, replaceWith("age", "[AGE]"))
+	}
+	for _, pattern := range graduationPhrasePatterns {
+		text = replaceSubmatch(text, pattern, 1, replaceWith("graduation_year", "[YEAR]"))
+	}
+	text = qualificationLinePattern.ReplaceAllStringFunc(text, func(line string) string {
+		return graduationYearPattern.ReplaceAllStringFunc(line, replaceWith("graduation_year", "[YEAR]"))
+	})
+	text = replaceSubmatch(text, nationalityPattern, 1, replaceWith("nationality", "[REMOVED]"))
+	for _, pattern := range maritalPatterns {
+		text = replaceSubmatch(text, pattern, 1, replaceWith("marital_status", "[REMOVED]"))
+	}
+	return text, items
+}
+
+// findCandidateNames finds the candidate's name from "Name:" lines and the first line.
+func findCandidateNames(text string) (labelled []string, firstLine []string) {
+	labelled = make([]string, 0)
+	for _, m := range nameLabelPattern.FindAllStringSubmatch(text, -1) {
+		if name := strings.TrimSpace(m[1]); labelledNamePattern.MatchString(name) && !slices.Contains(labelled, name) {
+			labelled = append(labelled, name)
+		}
+	}
+	firstLine = make([]string, 0)
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if looksLikeName(line) && !slices.Contains(labelled, line) {
+			firstLine = append(firstLine, line)
+		}
+		break
+	}
+	return labelled, firstLine
+}
+
+// nonNameWords are capitalised heading words that are not names.
+var nonNameWords = []string{
+	"curriculum", "vitae", "resume", "cv", "profile", "summary", "contact", "details", "personal",
+	"senior", "junior", "lead", "principal", "head", "chief", "staff", "graduate", "intern", "trainee",
+	"software", "data", "web", "cloud", "full", "stack", "backend", "frontend", "front", "back", "end", "mobile", "devops", "go", "java", "python",
+	"engineer", "developer", "programmer", "architect", "manager", "director", "analyst", "consultant", "designer",
+	"scientist", "accountant", "specialist", "administrator", "assistant", "officer", "executive", "coordinator", "technician",
+}
+
+// looksLikeName reports whether s is two to four capitalised words that are not headings.
+func looksLikeName(s string) bool {
+	words := strings.Fields(s)
+	if len(words) < 2 || len(words) > 4 {
+		return false
+	}
+	for _, w := range words {
+		if !namePartPattern.MatchString(w) || slices.Contains(nonNameWords, strings.ToLower(strings.TrimSuffix(w, "."))) {
+			return false
+		}
+	}
+	return true
+}
+
+// isCommonWord reports whether a name part is also used as an ordinary word.
+func isCommonWord(part string, text string) bool {
+	lower := strings.ToLower(part)
+	return slices.Contains(nonNameWords, lower) || (lower != part && len(wordIndices(text, lower)) > 0)
+}
+
+// wordIndices returns the start of every whole-word occurrence of word in text.
+func wordIndices(text string, word string) []int {
+	indices := make([]int, 0)
+	if word == "" {
+		return indices
+	}
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], word)
+		if i == -1 {
+			break
+		}
+		i += start
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[i+len(word):])
+		if !isWordRune(before) && !isWordRune(after) {
+			indices = append(indices, i)
+			start = i + len(word)
+		} else {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			start = i + size
+		}
+	}
+	return indices
+}
+
+// replaceWord replaces every whole-word occurrence of word in text.
+func replaceWord(text string, word string, replace func(string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, i := range wordIndices(text, word) {
+		sb.WriteString(text[last:i])
+		sb.WriteString(replace(word))
+		last = i + len(word)
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+// matchCase returns replacement with the same capitalisation as original.
+func matchCase(replacement string, original string) string {
+	runes := []rune(original)
+	if len(runes) > 1 && strings.ToUpper(original) == original {
+		return strings.ToUpper(replacement)
+	}
+	if unicode.IsUpper(runes[0]) {
+		return strings.ToUpper(replacement[:1]) + replacement[1:]
+	}
+	return replacement
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymiseResume(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		removed  []string
+		retained []string
+	}{
+		{
+			"first line name",
+			"Alice Smith\nAlice Smith led the team. Smith & Co is a client.",
+			[]string{"Alice Smith"},
+			[]string{"Smith & Co"},
+		},
+		{
+			"labelled name parts",
+			"Name: Alice Smith\nAlice led the team, reporting to Dr Smith.",
+			[]string{"Alice", "Smith"},
+			nil,
+		},
+		{
+			"job title header is not a name",
+			"Senior Go Developer\nSenior engineer writing Go services. Developer advocate.",
+			nil,
+			[]string{"Senior Go Developer", "Senior engineer", "Go services", "Developer advocate"},
+		},
+		{
+			"name parts that are common words",
+			"Name: Grace Young\nMentored young engineers with grace. Grace Young won an award.",
+			[]string{"Grace Young"},
+			[]string{"young engineers", "with grace"},
+		},
+		{
+			"name inside a longer word",
+			"Ann Lee\nAnnual reviews for Ann Lee.",
+			[]string{"Ann Lee"},
+			[]string{"Annual"},
+		},
+		{
+			"graduation years",
+			"Graduated in 2015\nClass of 2012\nBSc Computer Science, University of Leeds, 2012-2015\n2016 graduate",
+			[]string{"2015", "2012", "2016"},
+			[]string{"University of Leeds"},
+		},
+		{
+			"employment years",
+			"Tutor, Code Academy 2019–2023\nTeaching Assistant, University of Leeds 2016-2018\nScrum Master, Acme 2014-2016",
+			nil,
+			[]string{"2019–2023", "2016-2018", "2014-2016"},
+		},
+		{
+			"accented name",
+			"Name: José García\nJosé led the team. García and Zoë wrote the docs.",
+			[]string{"José", "García"},
+			[]string{"Zoë wrote"},
+		},
+		{
+			"accented first line name",
+			"Zoë Brontë\nZoë Brontë built the platform. Zoëtrope is a client.",
+			[]string{"Zoë Brontë built"},
+			[]string{"Zoëtrope"},
+		},
+		{
+			"non-Latin names",
+			"Name: Иван Петров\nИван led the team.\n\nName: 王小明\n王小明 wrote the docs.",
+			[]string{"Иван", "Петров", "王小明"},
+			nil,
+		},
+		{
+			"photo captions",
+			"Photo: headshot.jpg\n[Image]\nPortrait\nExperience",
+			[]string{"headshot.jpg", "[Image]", "Portrait"},
+			[]string{"Experience"},
+		},
+		{
+			"lines starting with photo words",
+			"Image processing pipeline with OpenCV, 2021\nPhotography club lead\nPortrait mode for the camera app",
+			nil,
+			[]string{"Image processing pipeline with OpenCV, 2021", "Photography club lead", "Portrait mode for the camera app"},
+		},
+		{
+			"combined demographic line",
+			"Gender: Male Nationality: British Marital status: Single",
+			[]string{"Male", "British", "Single"},
+			[]string{"Gender:", "Nationality:", "Marital status:"},
+		},
+		{
+			"demographics with punctuation",
+			"Sex: Female, Citizenship: New Zealand; Marital status: Married\nGender: Prefer not to say\nAge: 34",
+			[]string{"Female", "New Zealand", "Married", "Prefer not to say", "34"},
+			[]string{"Citizenship:", "Marital status:"},
+		},
+		{
+			"pronouns",
+			"She led the team and her work shipped.",
+			[]string{"She", "her"},
+			[]string{"They led the team and their work"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, _ := AnonymiseResume(c.text)
+			for _, s := range c.removed {
+				if strings.Contains(got, s) {
+					t.Errorf("AnonymiseResume() = %q, want %q removed", got, s)
+				}
+			}
+			for _, s := range c.retained {
+				if !strings.Contains(got, s) {
+					t.Errorf("AnonymiseResume() = %q, want %q kept", got, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Candidate is a person whose CV is being reviewed.
+type Candidate struct {
+	// ID is derived from the hash of the extracted text, so it is stable across runs.
+	ID string
+	// Path is the path of the CV file that is reviewed.
+	Path string
+	// Name is the name shown in reports, which is the base name of the file.
+	Name string
+	// Text is the text extracted from the CV file.
+	Text string
+	// Duplicates are the paths of the candidate's other CV files, which are not reviewed.
+	Duplicates []string
+	// RedactedText is the text after PII redaction.
+	RedactedText string
+	// ReviewedText is the text that is sent to the model, after PII redaction and anonymisation.
+	ReviewedText string
+	// PIIMapping maps the placeholders in RedactedText back to the personal information they replaced.
+	PIIMapping PIIMapping
+	// Anonymised lists what anonymisation removed, and is nil if anonymisation is disabled.
+	Anonymised []AnonymisedItem
+	// Profile is the structured information extracted from the CV, if any.
+	Profile *CandidateProfile
+}
+
+// candidateIDLength is the number of hex characters of the text hash used in IDs.
+const candidateIDLength = 12
+
+// NewCandidates creates a candidate for each duplicate group, in the same order.
+func NewCandidates(cfg Config, texts map[string]string, groups []DuplicateGroup) []Candidate {
+	candidates := make([]Candidate, len(groups))
+	for i, g := range groups {
+		c := Candidate{
+			ID:         hashText(texts[g.Primary])[:candidateIDLength],
+			Path:       g.Primary,
+			Name:       filepath.Base(g.Primary),
+			Text:       texts[g.Primary],
+			Duplicates: g.Duplicates,
+		}
+		c.RedactedText = c.Text
+		if len(cfg.PIIRedaction) > 0 {
+			c.RedactedText, c.PIIMapping = RedactPII(c.Text, cfg.PIIRedaction)
+		}
+		c.ReviewedText = c.RedactedText
+		if cfg.Anonymise {
+			c.ReviewedText, c.Anonymised = AnonymiseResume(c.RedactedText)
+		}
+		candidates[i] = c
+	}
+	// Files with identical text are only separate candidates if duplicate detection is disabled, in which case they are numbered in path order,
+	// whatever order the groups are in.
+	byPath := make([]int, len(candidates))
+	for i := range byPath {
+		byPath[i] = i
+	}
+	slices.SortFunc(byPath, func(a, b int) int { return strings.Compare(candidates[a].Path, candidates[b].Path) })
+	usedIDs := make(map[string]bool, len(candidates))
+	for _, i := range byPath {
+		baseID := candidates[i].ID
+		for n := 2; usedIDs[candidates[i].ID]; n++ {
+			candidates[i].ID = fmt.Sprintf("%s-%d", baseID, n)
+		}
+		usedIDs[candidates[i].ID] = true
+	}
+	return candidates
+}
+
+// QuestionText returns the text that questions are answered from.
+func (c Candidate) QuestionText(useOriginalText bool) string {
+	if useOriginalText {
+		return c.RedactedText
+	}
+	return c.ReviewedText
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// candidateIDs returns the IDs of the candidates keyed by their path.
+func candidateIDs(candidates []Candidate) map[string]string {
+	ids := make(map[string]string, len(candidates))
+	for _, c := range candidates {
+		ids[c.Path] = c.ID
+	}
+	return ids
+}
+
+func TestNewCandidatesIDs(t *testing.T) {
+	texts := map[string]string{
+		"pdf/alice.pdf": "Alice, backend engineer",
+		"pdf/bob.pdf":   "Bob, accountant",
+	}
+	ids := candidateIDs(NewCandidates(Config{}, texts, singleFileGroups(texts)))
+	if ids["pdf/alice.pdf"] == ids["pdf/bob.pdf"] {
+		t.Fatalf("different texts have the same ID %q", ids["pdf/alice.pdf"])
+	}
+	for _, id := range ids {
+		if len(id) != candidateIDLength {
+			t.Errorf("ID %q has %d characters, want %d", id, len(id), candidateIDLength)
+		}
+	}
+
+	// Renaming the files does not change their IDs.
+	renamed := map[string]string{
+		"pdf/cv_2.pdf": texts["pdf/alice.pdf"],
+		"pdf/cv_1.pdf": texts["pdf/bob.pdf"],
+	}
+	renamedIDs := candidateIDs(NewCandidates(Config{}, renamed, singleFileGroups(renamed)))
+	if renamedIDs["pdf/cv_2.pdf"] != ids["pdf/alice.pdf"] || renamedIDs["pdf/cv_1.pdf"] != ids["pdf/bob.pdf"] {
+		t.Errorf("IDs after renaming = %v, want the same IDs as before %v", renamedIDs, ids)
+	}
+
+	// Neither does the order of the groups.
+	groups := singleFileGroups(texts)
+	slices.Reverse(groups)
+	if reversed := candidateIDs(NewCandidates(Config{}, texts, groups)); !maps.Equal(reversed, ids) {
+		t.Errorf("IDs with the groups reversed = %v, want %v", reversed, ids)
+	}
+
+	// Nor does redaction or anonymisation, as the ID is from the extracted text.
+	cfg := Config{PIIRedaction: []PIICategory{PIIEmail}, Anonymise: true}
+	if redacted := candidateIDs(NewCandidates(cfg, texts, singleFileGroups(texts))); !maps.Equal(redacted, ids) {
+		t.Errorf("IDs with redaction and anonymisation = %v, want %v", redacted, ids)
+	}
+}
+
+func TestNewCandidatesIdenticalTexts(t *testing.T) {
+	const text = "Alice, backend engineer"
+	texts := map[string]string{
+		"pdf/c.pdf": text,
+		"pdf/a.pdf": text,
+		"pdf/b.pdf": text,
+		"pdf/d.pdf": "Bob, accountant",
+	}
+	groups := singleFileGroups(texts)
+	want := candidateIDs(NewCandidates(Config{}, texts, groups))
+	base := want["pdf/a.pdf"]
+	if want["pdf/b.pdf"] != base+"-2" || want["pdf/c.pdf"] != base+"-3" || strings.Contains(want["pdf/d.pdf"], "-") {
+		t.Errorf("IDs = %v, want identical texts numbered in path order after %q", want, base)
+	}
+
+	slices.Reverse(groups)
+	if got := candidateIDs(NewCandidates(Config{}, texts, groups)); !maps.Equal(got, want) {
+		t.Errorf("IDs with the groups reversed = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// ChecklistItemType is the kind of answer a checklist item asks the model for.
+type ChecklistItemType string
+
+const (
+	// ChecklistBoolean items are yes/no questions.
+	ChecklistBoolean ChecklistItemType = "boolean"
+	// ChecklistNumber items ask for a number, such as years of experience.
+	ChecklistNumber ChecklistItemType = "number"
+	// ChecklistRating items ask for a rating from ratingMin to ratingMax.
+	ChecklistRating ChecklistItemType = "rating"
+)
+
+var checklistItemTypes = []ChecklistItemType{ChecklistBoolean, ChecklistNumber, ChecklistRating}
+
+// ratingMin and ratingMax are the bounds of the scale that rating items are answered on.
+const (
+	ratingMin = 1
+	ratingMax = 5
+)
+
+// IsNumeric returns true if items of this type are answered with a number.
+func (t ChecklistItemType) IsNumeric() bool {
+	return t == ChecklistNumber || t == ChecklistRating
+}
+
+// ChecklistAggregate is how the numeric answers of the repeats are combined.
+type ChecklistAggregate string
+
+const (
+	AggregateMean   ChecklistAggregate = "mean"
+	AggregateMedian ChecklistAggregate = "median"
+)
+
+var checklistAggregates = []ChecklistAggregate{AggregateMean, AggregateMedian}
+
+// ScoringFunction is how the answer to a number or rating item is turned into a score.
+type ScoringFunction string
+
+const (
+	// ScoringLinear scores 0 at min and 1 at max, rising in a straight line between them.
+	ScoringLinear ScoringFunction = "linear"
+	// ScoringThreshold scores 1 if the answer is at least the threshold, and 0 otherwise.
+	ScoringThreshold ScoringFunction = "threshold"
+)
+
+var scoringFunctions = []ScoringFunction{ScoringLinear, ScoringThreshold}
+
+// ConfigChecklistScoring maps a numeric answer to a score between 0 and 1.
+type ConfigChecklistScoring struct {
+	// Function is linear if not set.
+	Function ScoringFunction `json:"function,omitempty"`
+	// Min and Max are the answers that score 0 and 1 with the linear function.
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+	// Threshold is the lowest answer that scores 1 with the threshold function, which requires it.
+	Threshold *float64 `json:"threshold,omitempty"`
+}
+
+// defaultRatingScoring scores ratings linearly from the lowest to the highest.
+var defaultRatingScoring = ConfigChecklistScoring{Function: ScoringLinear, Min: ratingMin, Max: ratingMax}
+
+// Score returns the score of an answer, from 0 to 1.
+func (s ConfigChecklistScoring) Score(value float64) float64 {
+	if s.Function == ScoringThreshold {
+		if s.Threshold != nil && value >= *s.Threshold {
+			return 1
+		}
+		return 0
+	}
+	if s.Min == s.Max {
+		if value >= s.Max {
+			return 1
+		}
+		return 0
+	}
+	return max(0, min(1, (value-s.Min)/(s.Max-s.Min)))
+}
+
+// Score returns the fraction of the item's weight that the candidate earns.
+func (c ConfigScoreChecklistItem) Score(result CandidateQuestionResult) float64 {
+	if !c.Type.IsNumeric() {
+		if result.IsTrue() {
+			return 1
+		}
+		return 0
+	}
+	scoring := defaultRatingScoring
+	if c.Scoring != nil {
+		scoring = *c.Scoring
+	}
+	return scoring.Score(result.Value())
+}
+
+// ChecklistScore returns the weighted sum of the scores of the checklist results.
+func ChecklistScore(checklist map[string]ConfigScoreChecklistItem, results map[string]CandidateQuestionResult) float64 {
+	score := 0.0
+	for key, result := range results {
+		item := checklist[key]
+		score += item.Weight * item.Score(result)
+	}
+	return score
+}
+
+// ChecklistQuestion is a checklist item as it is put to the model.
+type ChecklistQuestion struct {
+	Question  string
+	Type      ChecklistItemType
+	Aggregate ChecklistAggregate
+}
+
+// Prompt returns the question as shown to the model, with how to answer numeric items.
+func (q ChecklistQuestion) Prompt() string {
+	switch q.Type {
+	case ChecklistNumber:
+		return q.Question + " (answer with a number instead of true or false)"
+	case ChecklistRating:
+		return fmt.Sprintf("%s (answer with a whole number rating from %d to %d instead of true or false)", q.Question, ratingMin, ratingMax)
+	default:
+		return q.Question
+	}
+}
+
+// checklistAnswer is a boolean or numeric answer to a single checklist item.
+type checklistAnswer struct {
+	Boolean  bool
+	Number   float64
+	IsNumber bool
+}
+
+// UnmarshalJSON decodes a boolean or a number, rejecting null.
+func (a *checklistAnswer) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return errors.New("expected a boolean or a number, not null")
+	}
+	if err := json.Unmarshal(data, &a.Boolean); err == nil {
+		a.IsNumber = false
+		return nil
+	}
+	if err := json.Unmarshal(data, &a.Number); err != nil {
+		return errors.New("expected a boolean or a number")
+	}
+	a.IsNumber = true
+	return nil
+}
+
+// validateChecklistAnswers checks the type and evidence of every checklist answer.
+func validateChecklistAnswers(checklist map[string]ChecklistQuestion, resume string, answers map[string]responseItem[checklistAnswer]) error {
+	problems := make([]string, 0)
+	for _, key := range slices.Sorted(maps.Keys(answers)) {
+		answer := answers[key].Answer
+		switch checklist[key].Type {
+		case ChecklistNumber:
+			if !answer.IsNumber {
+				problems = append(problems, fmt.Sprintf("%q must be answered with a number, not true or false", key))
+			}
+		case ChecklistRating:
+			if !answer.IsNumber || answer.Number != math.Trunc(answer.Number) || answer.Number < ratingMin || answer.Number > ratingMax {
+				problems = append(problems, fmt.Sprintf("%q must be answered with a whole number from %d to %d", key, ratingMin, ratingMax))
+			}
+		default:
+			if answer.IsNumber {
+				problems = append(problems, fmt.Sprintf("%q must be answered with true or false, not a number", key))
+			}
+		}
+	}
+	problems = append(problems, evidenceProblems(resume, answers)...)
+	return problemsError(problems)
+}
+
+// aggregateValues combines the numeric answers of the repeats into a value and variance.
+func aggregateValues(values []float64, aggregate ChecklistAggregate) (value float64, variance float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+	if aggregate != AggregateMedian {
+		return mean, variance
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2, variance
+	}
+	return sorted[mid], variance
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestChecklistAnswerUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		data    string
+		want    checklistAnswer
+		wantErr bool
+	}{
+		{"true", checklistAnswer{Boolean: true}, false},
+		{"false", checklistAnswer{}, false},
+		{"3.5", checklistAnswer{Number: 3.5, IsNumber: true}, false},
+		{"0", checklistAnswer{IsNumber: true}, false},
+		{"null", checklistAnswer{}, true},
+		{" null ", checklistAnswer{}, true},
+		{`"yes"`, checklistAnswer{}, true},
+		{"[true]", checklistAnswer{}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.data, func(t *testing.T) {
+			var got checklistAnswer
+			err := got.UnmarshalJSON([]byte(c.data))
+			if (err != nil) != c.wantErr {
+				t.Fatalf("UnmarshalJSON(%s) error = %v, want error %v", c.data, err, c.wantErr)
+			}
+			if !c.wantErr && got != c.want {
+				t.Errorf("UnmarshalJSON(%s) = %+v, want %+v", c.data, got, c.want)
+			}
+		})
+	}
+	// A null answer inside a larger response must fail the whole response, not decode as false.
+	var resp map[string]checklistAnswer
+	if err := json.Unmarshal([]byte(`{"a": null}`), &resp); err == nil {
+		t.Errorf("json.Unmarshal accepted a null answer as %+v", resp["a"])
+	}
+}
+
+func TestAggregateValues(t *testing.T) {
+	cases := []struct {
+		name         string
+		values       []float64
+		aggregate    ChecklistAggregate
+		want         float64
+		wantVariance float64
+	}{
+		{"empty", nil, AggregateMean, 0, 0},
+		{"single", []float64{4}, AggregateMedian, 4, 0},
+		{"mean", []float64{1, 2, 6}, AggregateMean, 3, 14.0 / 3},
+		{"median odd", []float64{6, 1, 2}, AggregateMedian, 2, 14.0 / 3},
+		{"median even", []float64{4, 1, 2, 10}, AggregateMedian, 3, 12.1875},
+		{"unset is mean", []float64{1, 3}, "", 2, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, variance := aggregateValues(c.values, c.aggregate)
+			if !approxEqual(got, c.want) || !approxEqual(variance, c.wantVariance) {
+				t.Errorf("aggregateValues(%v, %q) = %v, %v, want %v, %v", c.values, c.aggregate, got, variance, c.want, c.wantVariance)
+			}
+		})
+	}
+}
+
+func TestConfigChecklistScoringScore(t *testing.T) {
+	cases := []struct {
+		name    string
+		scoring ConfigChecklistScoring
+		value   float64
+		want    float64
+	}{
+		{"linear middle", ConfigChecklistScoring{Min: 0, Max: 10}, 5, 0.5},
+		{"linear below min", ConfigChecklistScoring{Min: 2, Max: 10}, 0, 0},
+		{"linear above max", ConfigChecklistScoring{Min: 2, Max: 10}, 20, 1},
+		{"linear reversed", ConfigChecklistScoring{Function: ScoringLinear, Min: 10, Max: 0}, 2, 0.8},
+		{"linear empty range below", ConfigChecklistScoring{Min: 3, Max: 3}, 2, 0},
+		{"linear empty range at", ConfigChecklistScoring{Min: 3, Max: 3}, 3, 1},
+		{"linear unset range", ConfigChecklistScoring{}, 0, 1},
+		{"default rating lowest", defaultRatingScoring, ratingMin, 0},
+		{"default rating highest", defaultRatingScoring, ratingMax, 1},
+		{"default rating middle", defaultRatingScoring, 3, 0.5},
+		{"threshold below", ConfigChecklistScoring{Function: ScoringThreshold, Threshold: floatPtr(3)}, 2.9, 0},
+		{"threshold at", ConfigChecklistScoring{Function: ScoringThreshold, Threshold: floatPtr(3)}, 3, 1},
+		{"threshold above", ConfigChecklistScoring{Function: ScoringThreshold, Threshold: floatPtr(3)}, 8, 1},
+		{"threshold at zero", ConfigChecklistScoring{Function: ScoringThreshold, Threshold: floatPtr(0)}, 0, 1},
+		{"threshold unset", ConfigChecklistScoring{Function: ScoringThreshold}, 8, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.scoring.Score(c.value); !approxEqual(got, c.want) {
+				t.Errorf("Score(%v) = %v, want %v", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+// floatPtr returns a pointer to f, for optional config fields.
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// approxEqual reports whether two floats are equal, allowing for rounding errors.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestValidateChecklistAnswers(t *testing.T) {
+	checklist := map[string]ChecklistQuestion{
+		"go":      {Question: "Does the candidate know Go?", Type: ChecklistBoolean},
+		"years":   {Question: "How many years of experience?", Type: ChecklistNumber},
+		"clarity": {Question: "How clear is the CV?", Type: ChecklistRating},
+	}
+	const resume = "Backend engineer. Five years of Go at Acme."
+	boolean := func(b bool) checklistAnswer { return checklistAnswer{Boolean: b} }
+	number := func(n float64) checklistAnswer { return checklistAnswer{Number: n, IsNumber: true} }
+	cases := []struct {
+		name         string
+		answers      map[string]checklistAnswer
+		evidence     []string
+		wantProblems []string
+	}{
+		{"valid", map[string]checklistAnswer{"go": boolean(true), "years": number(5.5), "clarity": number(4)}, []string{"five years of Go"}, nil},
+		{"number for boolean", map[string]checklistAnswer{"go": number(1)}, nil, []string{`"go" must be answered with true or false`}},
+		{"boolean for number", map[string]checklistAnswer{"years": boolean(true)}, nil, []string{`"years" must be answered with a number`}},
+		{"boolean for rating", map[string]checklistAnswer{"clarity": boolean(false)}, nil, []string{`"clarity" must be answered with a whole number from 1 to 5`}},
+		{"fractional rating", map[string]checklistAnswer{"clarity": number(3.5)}, nil, []string{`"clarity" must be answered with a whole number`}},
+		{"rating below range", map[string]checklistAnswer{"clarity": number(0)}, nil, []string{`"clarity" must be answered with a whole number`}},
+		{"rating above range", map[string]checklistAnswer{"clarity": number(6)}, nil, []string{`"clarity" must be answered with a whole number`}},
+		{"rating at bounds", map[string]checklistAnswer{"clarity": number(ratingMax), "years": number(0)}, nil, nil},
+		{"invented evidence", map[string]checklistAnswer{"go": boolean(true)}, []string{"Ten years of Go"}, []string{`"go" has a quote in "evidence" that is not in the resume`}},
+		{"every problem", map[string]checklistAnswer{"go": number(1), "years": boolean(true)}, []string{""}, []string{`"go" must be answered with true or false`, `"years" must be answered with a number`, `"go" has an empty quote`}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			answers := make(map[string]responseItem[checklistAnswer], len(c.answers))
+			for k, a := range c.answers {
+				answers[k] = responseItem[checklistAnswer]{Reasoning: "because", Answer: a}
+			}
+			if c.evidence != nil {
+				item := answers["go"]
+				item.Evidence = c.evidence
+				answers["go"] = item
+			}
+			err := validateChecklistAnswers(checklist, resume, answers)
+			if c.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("validateChecklistAnswers() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateChecklistAnswers() error = nil, want problems %q", c.wantProblems)
+			}
+			if got := strings.Count(err.Error(), "\n- "); got != len(c.wantProblems) {
+				t.Errorf("error has %d problems, want %d: %v", got, len(c.wantProblems), err)
+			}
+			for _, p := range c.wantProblems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("error = %v, want it to contain %q", err, p)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregateNumericResults(t *testing.T) {
+	repeats := func(answers ...float64) []map[string]responseItem[checklistAnswer] {
+		results := make([]map[string]responseItem[checklistAnswer], len(answers))
+		for i, a := range answers {
+			results[i] = map[string]responseItem[checklistAnswer]{"years": {
+				Reasoning: fmt.Sprintf("repeat %d", i),
+				Answer:    checklistAnswer{Number: a, IsNumber: true},
+				Evidence:  []string{fmt.Sprintf("quote %d", i)},
+			}}
+		}
+		return results
+	}
+	cases := []struct {
+		name          string
+		answers       []float64
+		aggregate     ChecklistAggregate
+		wantValue     float64
+		wantVariance  float64
+		wantReasoning string
+	}{
+		{"single", []float64{4}, AggregateMean, 4, 0, "repeat 0"},
+		{"mean", []float64{2, 10, 3}, AggregateMean, 5, 38.0 / 3, "repeat 2"},
+		{"median", []float64{2, 10, 3}, AggregateMedian, 3, 38.0 / 3, "repeat 2"},
+		{"ties keep the first", []float64{1, 3}, AggregateMean, 2, 1, "repeat 0"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := aggregateNumericResults(repeats(c.answers...), "years", c.aggregate)
+			if !got.IsNumeric() || got.IsTrue() || !approxEqual(got.Value(), c.wantValue) || !approxEqual(got.variance, c.wantVariance) {
+				t.Errorf("aggregateNumericResults() = %+v, want a numeric value of %v with variance %v", got, c.wantValue, c.wantVariance)
+			}
+			wantEvidence := "quote " + strings.TrimPrefix(c.wantReasoning, "repeat ")
+			if got.Reasoning() != c.wantReasoning || len(got.Evidence()) != 1 || got.Evidence()[0] != wantEvidence {
+				t.Errorf("explanation = %q %q, want %q %q from the repeat closest to the value", got.Reasoning(), got.Evidence(), c.wantReasoning, wantEvidence)
+			}
+		})
+	}
+}
+
+func TestChecklistScore(t *testing.T) {
+	checklist := map[string]ConfigScoreChecklistItem{
+		"go":      {Question: "Does the candidate know Go?", Weight: 2, Type: ChecklistBoolean},
+		"years":   {Question: "How many years of experience?", Weight: 3, Type: ChecklistNumber, Scoring: &ConfigChecklistScoring{Min: 0, Max: 10}},
+		"senior":  {Question: "How many years of experience?", Weight: 1, Type: ChecklistNumber, Scoring: &ConfigChecklistScoring{Function: ScoringThreshold, Threshold: floatPtr(5)}},
+		"clarity": {Question: "How clear is the CV?", Weight: 4, Type: ChecklistRating},
+	}
+	numeric := func(answers ...float64) []map[string]responseItem[checklistAnswer] {
+		results := make([]map[string]responseItem[checklistAnswer], len(answers))
+		for i, a := range answers {
+			results[i] = map[string]responseItem[checklistAnswer]{"v": {Answer: checklistAnswer{Number: a, IsNumber: true}}}
+		}
+		return results
+	}
+	results := map[string]CandidateQuestionResult{
+		"go": {probability: 2.0 / 3},
+		// A mean of 4 years scores 0.4 linearly, but is below the threshold of 5.
+		"years":  aggregateNumericResults(numeric(2, 4, 6), "v", AggregateMean),
+		"senior": aggregateNumericResults(numeric(2, 4, 6), "v", AggregateMean),
+		// A median rating of 4 scores 0.75 with the default rating scoring.
+		"clarity": aggregateNumericResults(numeric(1, 4, 5), "v", AggregateMedian),
+	}
+	want := 2*1 + 3*0.4 + 1*0 + 4*0.75
+	if got := ChecklistScore(checklist, results); !approxEqual(got, want) {
+		t.Errorf("ChecklistScore() = %v, want %v", got, want)
+	}
+	if got := ChecklistScore(checklist, map[string]CandidateQuestionResult{}); got != 0 {
+		t.Errorf("ChecklistScore() with no results = %v, want 0", got)
+	}
+}
@@ -0,0 +1,784 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serveTokenEnvVar holds the bearer token that API requests must present, if set.
+const serveTokenEnvVar = "CVSCAN_SERVE_TOKEN"
+
+// maxUploadBytes is the largest request body accepted when creating a job.
+const maxUploadBytes = 64 << 20
+
+// serveMain runs the serve command, which exposes scanning over an HTTP API.
+func serveMain(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cvscan serve [flags]\n\nRuns an HTTP server that reviews uploaded CVs with the views in the config.\n\n")
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "127.0.0.1:8080", "the address to listen on")
+	jobsDir := fs.String("jobs-dir", "./jobs", "the directory that uploaded CVs are stored in, one subdirectory per job")
+	configPath := fs.String("config", "", "path to the config file (.json, .yaml, .yml or .toml), if not specified the single config.json, config.yaml, config.yml or config.toml in the current directory is used")
+	numRepeats := fs.Int("r", 5, "number of repeats to run, higher is more accurate but costs more and is slower")
+	maxConcurrentConnections := fs.Int("c", 3, "maximum number of concurrent connections to the LLM API, shared by every job")
+	apiKey := fs.String("k", "", "the openai api key (prefer the CVSCAN_API_KEY or OPENAI_API_KEY environment variables, a .env file, or -key-file, as flags are visible to other users)")
+	keyFile := fs.String("key-file", "", "path to a secrets file containing only the openai api key")
+	apiUrl := fs.String("u", "https://api.openai.com/v1/chat/completions", "the openai api url (or a url of any other openai-format api)")
+	modelName := fs.String("m", "gpt-4.1", "the name of the model to use for everything")
+	logFlags := addLogFlags(fs)
+	structuredOutput := fs.Bool("s", false, "if specified, uses the provider's structured output feature to force responses to match a JSON schema")
+	dedup := fs.Bool("dedup", false, "if specified, candidates within a job who submitted more than one CV are detected and only reviewed once")
+	dedupSimilarity := fs.Float64("dedup-similarity", 0.9, "how similar (from 0 to 1) the text of two CVs must be for them to be considered duplicates")
+	textCacheDir := fs.String("text-cache", "./text_cache", "directory to cache the text extracted from PDFs in (empty to disable)")
+	jobTTL := fs.Duration("job-ttl", 24*time.Hour, "how long finished jobs and their results are kept for (0 to keep them until the server restarts)")
+	fs.Parse(args)
+
+	resolvedKey, keySource, keyErr := ResolveAPIKey(*apiKey, *keyFile, ".env")
+	token := strings.TrimSpace(os.Getenv(serveTokenEnvVar))
+	logger := logFlags.newLogger(os.Stderr, resolvedKey, token)
+
+	if keyErr != nil {
+		logger.Error("Failed to find API key", "err", keyErr)
+		os.Exit(1)
+	}
+	if resolvedKey == "" {
+		logger.Error("API key must be specified, with the CVSCAN_API_KEY or OPENAI_API_KEY environment variables, a .env file, -key-file, or -k")
+		os.Exit(1)
+	}
+	logger.Info("Using API key", "source", keySource)
+	if token == "" {
+		logger.Warn("No API token set, anyone who can reach the server can use it", "env_var", serveTokenEnvVar)
+	}
+
+	if *configPath == "" {
+		var err error
+		*configPath, err = FindConfigFile(".")
+		if err != nil {
+			logger.Error("Failed to find config", "err", err)
+			os.Exit(1)
+		}
+	}
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		logger.Error("Failed to load config", "err", err)
+		os.Exit(1)
+	}
+
+	modelBuilder, err := NewModelBuilder(resolvedKey, *apiUrl, *modelName, *maxConcurrentConnections, Budget{}, *structuredOutput)
+	if err != nil {
+		logger.Error("Failed to create model builder", "err", err)
+		os.Exit(1)
+	}
+
+	server := &scanServer{
+		logger:          logger,
+		cfg:             cfg,
+		configPath:      *configPath,
+		modelBuilder:    modelBuilder,
+		jobsDir:         *jobsDir,
+		textCacheDir:    *textCacheDir,
+		numRepeats:      *numRepeats,
+		modelName:       *modelName,
+		dedup:           *dedup,
+		dedupSimilarity: *dedupSimilarity,
+		token:           token,
+		jobTTL:          *jobTTL,
+		jobs:            make(map[string]*scanJob),
+	}
+	httpServer := &http.Server{Addr: *addr, Handler: server.Handler()}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		logger.Info("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				server.pruneJobs(now)
+			}
+		}
+	}()
+
+	logger.Info("Listening, open the address in a browser to use the web UI", "addr", *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Server failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+type scanJobStatus string
+
+const (
+	jobQueued  scanJobStatus = "queued"
+	jobRunning scanJobStatus = "running"
+	jobDone    scanJobStatus = "done"
+	jobFailed  scanJobStatus = "failed"
+)
+
+// scanJob is a set of uploaded CVs being reviewed with some views.
+type scanJob struct {
+	ID     string        `json:"id"`
+	Status scanJobStatus `json:"status"`
+	Views  []string      `json:"views"`
+	// ViewsDone is the number of views that have finished reviewing every candidate.
+	ViewsDone int `json:"views_done"`
+	// Progress counts the LLM calls of every view of the job.
+	Progress   *ProgressSnapshot `json:"progress,omitempty"`
+	Files      []string          `json:"files"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt time.Time         `json:"finished_at,omitzero"`
+
+	dir      string
+	progress *Progress
+	// cfg is the config when the job was created.
+	cfg     Config
+	reports map[string][]CandidateReport
+	texts   map[string]jobFileText
+}
+
+// jobFileText is the text of an uploaded file, keyed by its name in the job.
+type jobFileText struct {
+	FileName string `json:"file_name"`
+	// CandidateID is the ID of the candidate the file was reviewed as, and is empty for duplicates.
+	CandidateID string `json:"candidate_id,omitempty"`
+	// Text is the text extracted from the file.
+	Text string `json:"text"`
+	// ReviewedText is the text sent to the LLM, and is empty for duplicates.
+	ReviewedText string `json:"reviewed_text"`
+}
+
+// scanServer serves the HTTP API, keeping jobs in memory.
+type scanServer struct {
+	logger          *slog.Logger
+	configPath      string
+	modelBuilder    ModelBuilder
+	jobsDir         string
+	textCacheDir    string
+	numRepeats      int
+	modelName       string
+	dedup           bool
+	dedupSimilarity float64
+	token           string
+	// jobTTL is how long finished jobs are kept for, or forever if it is zero.
+	jobTTL time.Duration
+
+	mu   sync.Mutex
+	cfg  Config
+	jobs map[string]*scanJob
+}
+
+// Handler returns the HTTP handler for the API and the web UI.
+func (s *scanServer) Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/views", s.handleListViews)
+	api.HandleFunc("GET /api/config", s.handleGetConfig)
+	api.HandleFunc("POST /api/config/validate", s.handleValidateConfig)
+	api.HandleFunc("PUT /api/config", s.handleSaveConfig)
+	api.HandleFunc("GET /api/jobs", s.handleListJobs)
+	api.HandleFunc("POST /api/jobs", s.handleCreateJob)
+	api.HandleFunc("GET /api/jobs/{id}", s.handleGetJob)
+	api.HandleFunc("DELETE /api/jobs/{id}", s.handleDeleteJob)
+	api.HandleFunc("GET /api/jobs/{id}/results", s.handleGetResults)
+	api.HandleFunc("GET /api/jobs/{id}/results/{view}", s.handleGetViewResults)
+	api.HandleFunc("GET /api/jobs/{id}/files/{name}", s.handleGetJobFile)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", s.requireToken(api))
+	mux.Handle("/", webUIHandler())
+	return mux
+}
+
+// requireToken rejects requests that do not present the server's bearer token, if one is set.
+func (s *scanServer) requireToken(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(s.token)) != 1 {
+			writeJSONError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type viewResponse struct {
+	Name              string   `json:"name"`
+	PrettyName        string   `json:"pretty_name"`
+	ScoreChecklist    []string `json:"score_checklist"`
+	SpecificQuestions []string `json:"specific_questions"`
+}
+
+func (s *scanServer) handleListViews(w http.ResponseWriter, r *http.Request) {
+	cfg := s.config()
+	views := make([]viewResponse, 0, len(cfg.Views))
+	for _, name := range slices.Sorted(maps.Keys(cfg.Views)) {
+		view := cfg.Views[name]
+		views = append(views, viewResponse{
+			Name:              name,
+			PrettyName:        view.PrettyName,
+			ScoreChecklist:    slices.AppendSeq([]string{}, maps.Keys(view.ScoreChecklist)),
+			SpecificQuestions: slices.AppendSeq([]string{}, maps.Keys(view.SpecificQuestions)),
+		})
+		slices.Sort(views[len(views)-1].ScoreChecklist)
+		slices.Sort(views[len(views)-1].SpecificQuestions)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"views": views})
+}
+
+// handleGetConfig returns the config file as JSON, exactly as written.
+func (s *scanServer) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	data, err := ReadConfigAsJSON(s.configPath)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"file_name":     filepath.Base(s.configPath),
+		"config":        json.RawMessage(data),
+		"save_disabled": s.saveDisabledReason(),
+	})
+}
+
+// saveDisabledReason returns why the config cannot be saved, or an empty string.
+func (s *scanServer) saveDisabledReason() string {
+	if !strings.EqualFold(filepath.Ext(s.configPath), ".json") {
+		return fmt.Sprintf("%s is not a JSON file, so it can only be changed by editing it directly", filepath.Base(s.configPath))
+	}
+	return ""
+}
+
+// reportOptions returns the optional CSV report columns of a job.
+func (s *scanServer) reportOptions(job scanJob) CSVReportOptions {
+	return CSVReportOptions{Duplicates: s.dedup, ProfileFields: job.cfg.ProfileReportColumns(), Now: job.CreatedAt}
+}
+
+// parseSubmittedConfig decodes and validates a JSON config sent to the API.
+func (s *scanServer) parseSubmittedConfig(data []byte) (Config, error) {
+	dir := filepath.Dir(s.configPath)
+	if cfg, err := decodeConfigStrict[Config](data); err == nil {
+		if err := ValidateConfigFilesWithin(cfg, dir); err != nil {
+			return Config{}, err
+		}
+	}
+	return ParseConfigJSON(data, dir)
+}
+
+// handleValidateConfig checks a JSON config in the request body without saving it.
+func (s *scanServer) handleValidateConfig(w http.ResponseWriter, r *http.Request) {
+	if data, ok := s.readConfigBody(w, r); ok {
+		if _, err := s.parseSubmittedConfig(data); err != nil {
+			writeJSON(w, http.StatusOK, map[string]any{"valid": false, "errors": ConfigErrors(err)})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"valid": true, "errors": []*ConfigError{}})
+	}
+}
+
+// handleSaveConfig validates a JSON config and writes it to the config file.
+func (s *scanServer) handleSaveConfig(w http.ResponseWriter, r *http.Request) {
+	if reason := s.saveDisabledReason(); reason != "" {
+		writeJSONError(w, http.StatusConflict, reason)
+		return
+	}
+	data, ok := s.readConfigBody(w, r)
+	if !ok {
+		return
+	}
+	cfg, err := s.parseSubmittedConfig(data)
+	if err == nil {
+		err = rejectGroupFileIncludes(data)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"valid": false, "errors": ConfigErrors(err)})
+		return
+	}
+	var encoded bytes.Buffer
+	err = json.Indent(&encoded, data, "", "    ")
+	if err == nil {
+		err = WriteTextFile(s.configPath, encoded.String()+"\n")
+	}
+	if err != nil {
+		s.logger.Error("Failed to save config", "err", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to save config")
+		return
+	}
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	s.logger.Info("Saved config from the API", "file", s.configPath)
+	writeJSON(w, http.StatusOK, map[string]any{"valid": true, "errors": []*ConfigError{}})
+}
+
+// rejectGroupFileIncludes returns a *ConfigError for every group file that a JSON config includes.
+func rejectGroupFileIncludes(data []byte) error {
+	cfg, err := decodeConfigStrict[Config](data)
+	if err != nil {
+		return err
+	}
+	errs := make([]error, 0)
+	for _, name := range slices.Sorted(maps.Keys(cfg.Views)) {
+		for i, include := range cfg.Views[name].Include {
+			if isGroupFile(include) {
+				errs = append(errs, &ConfigError{
+					Path:    fmt.Sprintf("%s[%d]", joinConfigPath("views", name, "include"), i),
+					Message: fmt.Sprintf("configs that include group files such as %s cannot be saved from the API, edit the config file directly or move the group into the config's groups", include),
+				})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readConfigBody reads a JSON config from the request body.
+func (s *scanServer) readConfigBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUploadBytes))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request: %v", err))
+		return nil, false
+	}
+	if !json.Valid(data) {
+		writeJSONError(w, http.StatusBadRequest, "config must be JSON")
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *scanServer) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	jobs := make([]scanJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.snapshot())
+	}
+	s.mu.Unlock()
+	slices.SortFunc(jobs, func(a, b scanJob) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+// handleCreateJob starts a job for the PDF files and views of a multipart form.
+func (s *scanServer) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid upload: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	views := make([]string, 0)
+	for _, v := range r.MultipartForm.Value["views"] {
+		for name := range strings.SplitSeq(v, ",") {
+			if name = strings.TrimSpace(name); name != "" && !slices.Contains(views, name) {
+				views = append(views, name)
+			}
+		}
+	}
+	cfg := s.config()
+	if len(views) == 0 {
+		views = slices.Sorted(maps.Keys(cfg.Views))
+	}
+	for _, name := range views {
+		if _, ok := cfg.Views[name]; !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("unknown view %q", name))
+			return
+		}
+	}
+	files := r.MultipartForm.File["files"]
+	if len(files) == 0 {
+		writeJSONError(w, http.StatusBadRequest, `at least one PDF must be uploaded in the "files" field`)
+		return
+	}
+	for _, fh := range files {
+		if !strings.HasSuffix(strings.ToLower(fh.Filename), ".pdf") {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("file %q is not a PDF", fh.Filename))
+			return
+		}
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to create job")
+		return
+	}
+	job := &scanJob{
+		ID:        id,
+		Status:    jobQueued,
+		Views:     views,
+		Files:     make([]string, 0, len(files)),
+		CreatedAt: time.Now(),
+		dir:       filepath.Join(s.jobsDir, id),
+		cfg:       cfg,
+		progress:  NewProgress(),
+	}
+	if err := os.MkdirAll(job.dir, os.ModePerm); err != nil {
+		s.logger.Error("Failed to create job directory", "err", err, "job_id", id)
+		writeJSONError(w, http.StatusInternalServerError, "failed to store uploaded files")
+		return
+	}
+	for _, fh := range files {
+		name := filepath.Base(fh.Filename)
+		// Two uploads may have the same name, so later ones are numbered to keep every file.
+		for i := 2; slices.Contains(job.Files, name); i++ {
+			name = fmt.Sprintf("%s_%d.pdf", strings.TrimSuffix(filepath.Base(fh.Filename), filepath.Ext(fh.Filename)), i)
+		}
+		if err := saveUploadedFile(fh, filepath.Join(job.dir, name)); err != nil {
+			s.logger.Error("Failed to save uploaded file", "err", err, "job_id", id, "file", name)
+			writeJSONError(w, http.StatusInternalServerError, "failed to store uploaded files")
+			return
+		}
+		job.Files = append(job.Files, name)
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	created := job.snapshot()
+	s.mu.Unlock()
+	s.logger.Info("Created job", "job_id", id, "num_files", len(job.Files), "views", views)
+	go s.runJob(job)
+
+	w.Header().Set("Location", "/api/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, created)
+}
+
+func (s *scanServer) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getJob(r.PathValue("id"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleDeleteJob deletes a finished job and its results.
+func (s *scanServer) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[r.PathValue("id")]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	if job.FinishedAt.IsZero() {
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("job is %s, it can only be deleted once it has finished", job.Status))
+		return
+	}
+	s.deleteJob(job)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pruneJobs deletes the jobs that finished longer than the job TTL before now.
+func (s *scanServer) pruneJobs(now time.Time) {
+	if s.jobTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if !job.FinishedAt.IsZero() && now.Sub(job.FinishedAt) > s.jobTTL {
+			s.deleteJob(job)
+		}
+	}
+}
+
+// deleteJob forgets the job and removes its files, with the server's lock held.
+func (s *scanServer) deleteJob(job *scanJob) {
+	delete(s.jobs, job.ID)
+	if err := os.RemoveAll(job.dir); err != nil {
+		s.logger.Warn("Failed to remove job files", "err", err, "job_id", job.ID)
+	}
+	s.logger.Info("Deleted job", "job_id", job.ID)
+}
+
+func (s *scanServer) handleGetResults(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getFinishedJob(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	views := make(map[string][]candidateReportResponse, len(job.reports))
+	for name, reports := range job.reports {
+		views[name] = newCandidateReportResponses(reports)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job, "views": views})
+}
+
+// handleGetViewResults returns the ranked reports of one view as JSON or CSV.
+func (s *scanServer) handleGetViewResults(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getFinishedJob(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	viewName := r.PathValue("view")
+	reports, ok := job.reports[viewName]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("job has no results for view %q", viewName))
+		return
+	}
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, viewReportResponse{View: viewName, Candidates: newCandidateReportResponses(reports)})
+	case "csv":
+		mode, ok := reportModesByName[r.URL.Query().Get("mode")]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "mode must be report, probabilities or inconsistency")
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		if err := WriteCandidateReportsAsCSV(w, reports, mode, s.reportOptions(job)); err != nil {
+			s.logger.Error("Failed to write CSV results", "err", err, "job_id", job.ID)
+		}
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := WriteCandidateReportsAsHTML(w, viewTitle(job.cfg.Views[viewName], viewName), reports); err != nil {
+			s.logger.Error("Failed to write HTML results", "err", err, "job_id", job.ID)
+		}
+	default:
+		writeJSONError(w, http.StatusBadRequest, "format must be json, csv or html")
+	}
+}
+
+// handleGetJobFile returns the extracted and reviewed text of one of the job's files.
+func (s *scanServer) handleGetJobFile(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.getJob(r.PathValue("id"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	text, ok := job.texts[r.PathValue("name")]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "file not found, or its text has not been extracted yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, text)
+}
+
+// reportModesByName maps the mode query parameter to report modes.
+var reportModesByName = map[string]ReportMode{
+	"":              Boolean,
+	"report":        Boolean,
+	"probabilities": Probability,
+	"inconsistency": Inconsistency,
+}
+
+// getJob returns a copy of the job, which is safe to read while the job is running.
+func (s *scanServer) getJob(id string) (scanJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return scanJob{}, false
+	}
+	return job.snapshot(), true
+}
+
+// snapshot returns a copy of the job, with the server's lock held.
+func (j *scanJob) snapshot() scanJob {
+	snapshot := *j
+	progress := j.progress.Snapshot()
+	snapshot.Progress = &progress
+	return snapshot
+}
+
+// getFinishedJob returns the job if it has finished successfully.
+func (s *scanServer) getFinishedJob(w http.ResponseWriter, id string) (scanJob, bool) {
+	job, ok := s.getJob(id)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return scanJob{}, false
+	}
+	switch job.Status {
+	case jobDone:
+		return job, true
+	case jobFailed:
+		writeJSONError(w, http.StatusConflict, "job failed: "+job.Error)
+	default:
+		writeJSONError(w, http.StatusConflict, fmt.Sprintf("job is %s, results are not ready yet", job.Status))
+	}
+	return scanJob{}, false
+}
+
+// runJob reviews the files of the job with each of its views.
+func (s *scanServer) runJob(job *scanJob) {
+	logger := s.logger.With("job_id", job.ID)
+	s.setJobStatus(job, jobRunning, nil, nil)
+	tstart := time.Now()
+
+	reports, err := s.reviewJobFiles(logger, job)
+	if err != nil {
+		logger.Error("Job failed", "err", err)
+		s.setJobStatus(job, jobFailed, err, nil)
+		return
+	}
+	logger.Info("Job finished", "time_taken", time.Since(tstart))
+	s.setJobStatus(job, jobDone, nil, reports)
+}
+
+// readJobFiles extracts the text of the job's uploaded files, then removes them.
+func (s *scanServer) readJobFiles(logger *slog.Logger, job *scanJob) (map[string]string, error) {
+	defer func() {
+		if err := os.RemoveAll(job.dir); err != nil {
+			logger.Warn("Failed to remove uploaded files", "err", err)
+		}
+	}()
+	var textCache *TextCache
+	if s.textCacheDir != "" {
+		var err error
+		// Each job has its own cache instance, as the hit counters are not safe to share, but they share the same files.
+		textCache, err = NewTextCache(s.textCacheDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return readPDFsFromDir(job.dir, textCache)
+}
+
+func (s *scanServer) reviewJobFiles(logger *slog.Logger, job *scanJob) (map[string][]CandidateReport, error) {
+	pdfs, err := s.readJobFiles(logger, job)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range slices.Sorted(maps.Keys(pdfs)) {
+		if HasTooLittleText(pdfs[path]) {
+			logger.Warn("Very little text could be extracted from the CV, it may be a scanned image, so it will be reviewed on almost no text and never treated as a duplicate", "path", filepath.Base(path))
+		}
+	}
+	groups := singleFileGroups(pdfs)
+	if s.dedup {
+		groups = FindDuplicates(pdfs, s.dedupSimilarity)
+	}
+	candidates := NewCandidates(job.cfg, pdfs, groups)
+	texts := make(map[string]jobFileText, len(pdfs))
+	for path, text := range pdfs {
+		texts[filepath.Base(path)] = jobFileText{FileName: filepath.Base(path), Text: text}
+	}
+	for _, c := range candidates {
+		t := texts[c.Name]
+		t.CandidateID = c.ID
+		t.ReviewedText = c.ReviewedText
+		texts[c.Name] = t
+	}
+	s.mu.Lock()
+	job.texts = texts
+	s.mu.Unlock()
+
+	if job.cfg.Profile != nil {
+		if err := extractProfiles(logger, s.modelBuilder, candidates, job.progress); err != nil {
+			return nil, err
+		}
+	}
+	runner := &viewRunner{
+		logger:        logger,
+		modelBuilder:  s.modelBuilder,
+		views:         job.cfg.Views,
+		candidates:    candidates,
+		numRepeats:    s.numRepeats,
+		modelName:     s.modelName,
+		reportOptions: s.reportOptions(*job),
+		progress:      job.progress,
+	}
+	var reportsLock sync.Mutex
+	reports := make(map[string][]CandidateReport)
+	err = ParMapDo(job.Views, func(viewName string) error {
+		viewReports, err := runner.reviewView(viewName)
+		if err != nil {
+			return err
+		}
+		reportsLock.Lock()
+		reports[viewName] = viewReports
+		reportsLock.Unlock()
+		s.mu.Lock()
+		job.ViewsDone++
+		s.mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// config returns the config that new jobs use.
+func (s *scanServer) config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+func (s *scanServer) setJobStatus(job *scanJob, status scanJobStatus, err error, reports map[string][]CandidateReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.Status = status
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if reports != nil {
+		job.reports = reports
+	}
+	if status == jobDone || status == jobFailed {
+		job.FinishedAt = time.Now()
+	}
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func saveUploadedFile(fh *multipart.FileHeader, path string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandleSaveConfig(t *testing.T) {
+	const validView = `"score_checklist": {"python": {"question": "Does the candidate know Python?"}}`
+	cases := []struct {
+		name       string
+		fileName   string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"valid", "config.json", `{"views": {"dev": {` + validView + `}}}`, http.StatusOK, ""},
+		{"yaml config", "config.yaml", `{"views": {"dev": {` + validView + `}}}`, http.StatusConflict, "not a JSON file"},
+		{"template outside the directory", "config.json", `{"views": {"dev": {` + validView + `, "templates": {"review": "../review.tmpl"}}}}`, http.StatusUnprocessableEntity, "views.dev.templates.review"},
+		{"job description outside the directory", "config.json", `{"views": {"dev": {` + validView + `, "job_description_file": "../jd.txt"}}}`, http.StatusUnprocessableEntity, "views.dev.job_description_file"},
+		{"group file outside the directory", "config.json", `{"views": {"dev": {` + validView + `, "include": ["../groups.json"]}}}`, http.StatusUnprocessableEntity, "views.dev.include[0]"},
+		{"group file", "config.json", `{"views": {"dev": {` + validView + `, "include": ["groups.json"]}}}`, http.StatusUnprocessableEntity, "cannot be saved"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, c.fileName)
+			const original = "views: {}\n"
+			for name, content := range map[string]string{c.fileName: original, "groups.json": `{"score_checklist": {"go": {"question": "Does the candidate know Go?"}}}`} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := &scanServer{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), configPath: configPath}
+			req := httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(c.body))
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+			if rec.Code != c.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, c.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), c.wantError) {
+				t.Errorf("response = %s, want it to contain %q", rec.Body.String(), c.wantError)
+			}
+			saved, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (string(saved) != original) != (c.wantStatus == http.StatusOK) {
+				t.Errorf("config file = %q after a response of %d", saved, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandleGetJobProgress(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)}
+	progress := newProgressWithClock(clock.Now)
+	view := progress.View("programmer")
+	view.AddTotal(4)
+	view.Done()
+	clock.now = clock.now.Add(30 * time.Second)
+	s := &scanServer{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		jobs:   map[string]*scanJob{"job1": {ID: "job1", Status: jobRunning, Views: []string{"programmer"}, progress: progress}},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/jobs/job1", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var job struct {
+		Status   scanJobStatus `json:"status"`
+		Progress struct {
+			CallsDone  int                             `json:"calls_done"`
+			CallsTotal int                             `json:"calls_total"`
+			ETASeconds int                             `json:"eta_seconds"`
+			Views      map[string]ViewProgressSnapshot `json:"views"`
+		} `json:"progress"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	p := job.Progress
+	if job.Status != jobRunning || p.CallsDone != 1 || p.CallsTotal != 4 || p.ETASeconds != 90 {
+		t.Errorf("job = %s, want a running job with 1/4 calls done and 90 seconds left", rec.Body.String())
+	}
+	if got := p.Views["programmer"]; got != (ViewProgressSnapshot{CallsDone: 1, CallsTotal: 4}) {
+		t.Errorf("programmer progress = %+v, want 1/4 calls", got)
+	}
+}
+
+// newTestServer returns a server with one view, "dev", that stores jobs in a temporary directory.
+func newTestServer(t *testing.T, token string) *scanServer {
+	return &scanServer{
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cfg:     Config{Views: map[string]ConfigView{"dev": {ScoreChecklist: map[string]ConfigScoreChecklistItem{"python": {Question: "Does the candidate know Python?"}}}}},
+		jobsDir: t.TempDir(),
+		token:   token,
+		jobs:    make(map[string]*scanJob),
+	}
+}
+
+// newTestJob returns a job with the status, as the server stores it.
+func newTestJob(id string, status scanJobStatus) *scanJob {
+	return &scanJob{ID: id, Status: status, progress: NewProgress()}
+}
+
+func serveTestRequest(s *scanServer, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireToken(t *testing.T) {
+	cases := []struct {
+		name       string
+		token      string
+		header     string
+		wantStatus int
+	}{
+		{"no token required", "", "", http.StatusOK},
+		{"missing token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"token without bearer", "secret", "secret", http.StatusUnauthorized},
+		{"empty bearer token", "secret", "Bearer ", http.StatusUnauthorized},
+		{"right token", "secret", "Bearer secret", http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/views", nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+			rec := serveTestRequest(newTestServer(t, c.token), req)
+			if rec.Code != c.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, c.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleCreateJobRejects(t *testing.T) {
+	cases := []struct {
+		name      string
+		files     []string
+		views     []string
+		wantError string
+	}{
+		{"no files", nil, nil, "at least one PDF"},
+		{"not a PDF", []string{"alice.pdf", "bob.docx"}, nil, `bob.docx\" is not a PDF`},
+		{"unknown view", []string{"alice.pdf"}, []string{"dev,designer"}, `unknown view \"designer`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			for _, name := range c.files {
+				fw, err := mw.CreateFormFile("files", name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fw.Write([]byte("%PDF-1.4"))
+			}
+			for _, v := range c.views {
+				mw.WriteField("views", v)
+			}
+			mw.Close()
+			s := newTestServer(t, "")
+			req := httptest.NewRequest(http.MethodPost, "/api/jobs", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			rec := serveTestRequest(s, req)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), c.wantError) {
+				t.Errorf("response = %d %s, want %d containing %q", rec.Code, rec.Body.String(), http.StatusBadRequest, c.wantError)
+			}
+			if entries, _ := os.ReadDir(s.jobsDir); len(s.jobs) > 0 || len(entries) > 0 {
+				t.Errorf("jobs = %v and job directories = %v, want none", s.jobs, entries)
+			}
+		})
+	}
+
+	t.Run("not a form", func(t *testing.T) {
+		rec := serveTestRequest(newTestServer(t, ""), httptest.NewRequest(http.MethodPost, "/api/jobs", strings.NewReader(`{"files": []}`)))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid upload") {
+			t.Errorf("response = %d %s, want %d invalid upload", rec.Code, rec.Body.String(), http.StatusBadRequest)
+		}
+	})
+}
+
+func TestGetFinishedJob(t *testing.T) {
+	cases := []struct {
+		id         string
+		wantStatus int
+		wantError  string
+	}{
+		{"queued", http.StatusConflict, "job is queued, results are not ready yet"},
+		{"running", http.StatusConflict, "job is running, results are not ready yet"},
+		{"failed", http.StatusConflict, "job failed: no PDFs found"},
+		{"done", http.StatusOK, ""},
+		{"missing", http.StatusNotFound, "job not found"},
+	}
+	s := newTestServer(t, "")
+	for _, status := range []scanJobStatus{jobQueued, jobRunning, jobFailed, jobDone} {
+		s.jobs[string(status)] = newTestJob(string(status), status)
+	}
+	s.jobs["failed"].Error = "no PDFs found"
+	s.jobs["done"].reports = map[string][]CandidateReport{"dev": {}}
+	for _, c := range cases {
+		t.Run(c.id, func(t *testing.T) {
+			for _, path := range []string{"/api/jobs/" + c.id + "/results", "/api/jobs/" + c.id + "/results/dev"} {
+				rec := serveTestRequest(s, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != c.wantStatus || !strings.Contains(rec.Body.String(), c.wantError) {
+					t.Errorf("%s response = %d %s, want %d containing %q", path, rec.Code, rec.Body.String(), c.wantStatus, c.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleDeleteJob(t *testing.T) {
+	s := newTestServer(t, "")
+	running := newTestJob("running", jobRunning)
+	done := newTestJob("done", jobDone)
+	done.FinishedAt = time.Now()
+	done.dir = filepath.Join(s.jobsDir, "done")
+	if err := os.MkdirAll(done.dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s.jobs["running"], s.jobs["done"] = running, done
+
+	cases := []struct {
+		id         string
+		wantStatus int
+	}{
+		{"running", http.StatusConflict},
+		{"done", http.StatusNoContent},
+		{"done", http.StatusNotFound},
+		{"missing", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		rec := serveTestRequest(s, httptest.NewRequest(http.MethodDelete, "/api/jobs/"+c.id, nil))
+		if rec.Code != c.wantStatus {
+			t.Errorf("DELETE %s status = %d, want %d: %s", c.id, rec.Code, c.wantStatus, rec.Body.String())
+		}
+	}
+	if _, ok := s.jobs["running"]; !ok {
+		t.Error("running job was deleted")
+	}
+	if _, err := os.Stat(done.dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("deleted job's directory still exists (%v)", err)
+	}
+}
+
+func TestPruneJobs(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		ttl      time.Duration
+		wantJobs []string
+	}{
+		{"expired jobs are deleted", time.Hour, []string{"recent", "running"}},
+		{"zero keeps every job", 0, []string{"old", "recent", "running"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestServer(t, "")
+			s.jobTTL = c.ttl
+			s.jobs["old"] = newTestJob("old", jobDone)
+			s.jobs["old"].FinishedAt = now.Add(-2 * time.Hour)
+			s.jobs["recent"] = newTestJob("recent", jobFailed)
+			s.jobs["recent"].FinishedAt = now.Add(-10 * time.Minute)
+			s.jobs["running"] = newTestJob("running", jobRunning)
+			s.pruneJobs(now)
+			if got := slices.Sorted(maps.Keys(s.jobs)); !slices.Equal(got, c.wantJobs) {
+				t.Errorf("jobs = %v, want %v", got, c.wantJobs)
+			}
+		})
+	}
+}
+
+func TestReadJobFilesRemovesUploads(t *testing.T) {
+	s := newTestServer(t, "")
+	job := newTestJob("job1", jobRunning)
+	job.dir = filepath.Join(s.jobsDir, "job1")
+	if err := os.MkdirAll(job.dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(job.dir, "notes.txt"), []byte("not a CV"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.readJobFiles(slog.New(slog.NewTextHandler(io.Discard, nil)), job); err != nil {
+		t.Fatalf("readJobFiles() error = %v", err)
+	}
+	if _, err := os.Stat(job.dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("job directory still exists after its files were read (%v)", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// suggestMain runs the suggest command, which drafts a view from a job description.
+func suggestMain(args []string) {
+	fs := flag.NewFlagSet("suggest", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cvscan suggest -j <job description file> [flags]\n\nDrafts a view (checklist and specific questions) from a job description, for you to edit before running cvscan.\n\n")
+		fs.PrintDefaults()
+	}
+	jobDescPath := fs.String("j", "", "path to the job description, as a text or PDF file, must always be specified")
+	viewName := fs.String("n", "suggested", "the name of the view to create")
+	outPath := fs.String("o", "suggested_config.json", "the file to write the suggested config to")
+	apiKey := fs.String("k", "", "the openai api key (prefer the CVSCAN_API_KEY or OPENAI_API_KEY environment variables, a .env file, or -key-file, as flags are visible to other users)")
+	keyFile := fs.String("key-file", "", "path to a secrets file containing only the openai api key")
+	apiUrl := fs.String("u", "https://api.openai.com/v1/chat/completions", "the openai api url (or a url of any other openai-format api)")
+	modelName := fs.String("m", "gpt-4.1", "the name of the model to use")
+	logFlags := addLogFlags(fs)
+	structuredOutput := fs.Bool("s", false, "if specified, uses the provider's structured output feature to force the response to match a JSON schema")
+	fs.Parse(args)
+
+	resolvedKey, keySource, keyErr := ResolveAPIKey(*apiKey, *keyFile, ".env")
+	logger := logFlags.newLogger(os.Stderr, resolvedKey)
+
+	if keyErr != nil {
+		logger.Error("Failed to find API key", "err", keyErr)
+		os.Exit(1)
+	}
+	if resolvedKey == "" {
+		logger.Error("API key must be specified, with the CVSCAN_API_KEY or OPENAI_API_KEY environment variables, a .env file, -key-file, or -k")
+		os.Exit(1)
+	}
+	logger.Info("Using API key", "source", keySource)
+	if *jobDescPath == "" {
+		logger.Error("Job description must be specified with -j")
+		os.Exit(1)
+	}
+
+	logger.Info("Reading job description")
+	jobDesc, err := readJobDescriptionFile(*jobDescPath)
+	if err != nil {
+		logger.Error("Failed to read job description", "err", err)
+		os.Exit(1)
+	}
+
+	modelBuilder, err := NewModelBuilder(resolvedKey, *apiUrl, *modelName, 1, Budget{}, *structuredOutput)
+	if err != nil {
+		logger.Error("Failed to create model builder", "err", err)
+		os.Exit(1)
+	}
+
+	view, err := SuggestView(logger, modelBuilder, jobDesc)
+	if err != nil {
+		logger.Error("Failed to suggest view", "err", err)
+		os.Exit(1)
+	}
+	// Paths in a config are relative to the config file, which is not necessarily in the current directory.
+	view.JobDescriptionFile, err = pathRelativeTo(filepath.Dir(*outPath), *jobDescPath)
+	if err != nil {
+		logger.Error("Failed to find the job description relative to the output file", "err", err)
+		os.Exit(1)
+	}
+
+	cfg := Config{Views: map[string]ConfigView{*viewName: view}}
+	if err := ValidateConfig(cfg); err != nil {
+		logger.Warn("The suggested config has problems, fix them before use", "err", err)
+	}
+	data, err := suggestedConfigJSON(cfg)
+	if err != nil {
+		logger.Error("Failed to encode suggested config", "err", err)
+		os.Exit(1)
+	}
+	if err := WriteTextFile(*outPath, string(data)); err != nil {
+		logger.Error("Failed to write suggested config", "err", err)
+		os.Exit(1)
+	}
+	logger.Info(
+		"Wrote suggested config, review and edit it before use",
+		"file", *outPath,
+		"num_checklist", len(view.ScoreChecklist),
+		"num_questions", len(view.SpecificQuestions),
+	)
+}
+
+// suggestedConfigJSON encodes the suggested config as the suggest command writes it.
+func suggestedConfigJSON(cfg Config) ([]byte, error) {
+	data, err := json.MarshalIndent(cfg, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// pathRelativeTo returns path as a path relative to dir.
+func pathRelativeTo(dir string, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		// There is no relative path between them, for example if they are on different drives.
+		return filepath.ToSlash(absPath), nil
+	}
+	return filepath.ToSlash(rel), nil
+}
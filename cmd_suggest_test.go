@@ -0,0 +1,144 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathRelativeTo(t *testing.T) {
+	cases := []struct {
+		dir, path, want string
+	}{
+		{".", "jd.txt", "jd.txt"},
+		{"configs", "jd.txt", "../jd.txt"},
+		{"configs", "configs/jd.txt", "jd.txt"},
+		{"a/b", "c/jd.pdf", "../../c/jd.pdf"},
+	}
+	for _, c := range cases {
+		got, err := pathRelativeTo(c.dir, c.path)
+		if err != nil {
+			t.Fatalf("pathRelativeTo(%q, %q) error: %v", c.dir, c.path, err)
+		}
+		if got != c.want {
+			t.Errorf("pathRelativeTo(%q, %q) = %q, want %q", c.dir, c.path, got, c.want)
+		}
+	}
+}
+
+func TestValidateSuggestedView(t *testing.T) {
+	valid := func() suggestViewResponse {
+		return suggestViewResponse{
+			PrettyName: "Backend Engineer",
+			ScoreChecklist: []suggestedChecklistItem{
+				{Key: "go_experience", Question: "Has the candidate used Go?", Weight: 3, Important: true},
+				{Key: "degree", Question: "Does the candidate have a degree?", Weight: 1},
+			},
+			SpecificQuestions: []suggestedSpecificQuestion{{Key: "notice_period", Question: "What is their notice period?"}},
+		}
+	}
+	cases := []struct {
+		name         string
+		edit         func(r *suggestViewResponse)
+		wantProblems []string
+	}{
+		{"valid", func(r *suggestViewResponse) {}, nil},
+		{"no questions", func(r *suggestViewResponse) { r.SpecificQuestions = nil }, nil},
+		{"highest weight", func(r *suggestViewResponse) { r.ScoreChecklist[0].Weight = 10 }, nil},
+		{"empty pretty name", func(r *suggestViewResponse) { r.PrettyName = " " }, []string{"pretty_name must not be empty"}},
+		{"empty checklist", func(r *suggestViewResponse) { r.ScoreChecklist = nil }, []string{"score_checklist must contain at least one item"}},
+		{"camel case key", func(r *suggestViewResponse) { r.ScoreChecklist[0].Key = "goExperience" }, []string{`score_checklist[0].key "goExperience" must be snake_case`}},
+		{"key starting with a digit", func(r *suggestViewResponse) { r.SpecificQuestions[0].Key = "2nd_language" }, []string{`specific_questions[0].key "2nd_language" must be snake_case`}},
+		{"empty key", func(r *suggestViewResponse) { r.ScoreChecklist[1].Key = "" }, []string{`score_checklist[1].key "" must be snake_case`}},
+		{"duplicate checklist key", func(r *suggestViewResponse) { r.ScoreChecklist[1].Key = "go_experience" }, []string{`score_checklist[1].key "go_experience" is used more than once`}},
+		{"key used by a checklist item and a question", func(r *suggestViewResponse) { r.SpecificQuestions[0].Key = "degree" }, []string{`specific_questions[0].key "degree" is used more than once`}},
+		{"empty question", func(r *suggestViewResponse) { r.ScoreChecklist[0].Question = "" }, []string{"score_checklist[0].question must not be empty"}},
+		{"empty specific question", func(r *suggestViewResponse) { r.SpecificQuestions[0].Question = "" }, []string{"specific_questions[0].question must not be empty"}},
+		{"zero weight", func(r *suggestViewResponse) { r.ScoreChecklist[0].Weight = 0 }, []string{"score_checklist[0].weight must be between 0 and 10"}},
+		{"negative weight", func(r *suggestViewResponse) { r.ScoreChecklist[1].Weight = -1 }, []string{"score_checklist[1].weight must be between 0 and 10"}},
+		{"weight above 10", func(r *suggestViewResponse) { r.ScoreChecklist[0].Weight = 11 }, []string{"score_checklist[0].weight must be between 0 and 10"}},
+		{"NaN weight", func(r *suggestViewResponse) { r.ScoreChecklist[0].Weight = math.NaN() }, []string{"score_checklist[0].weight must be between 0 and 10"}},
+		{"every problem", func(r *suggestViewResponse) {
+			r.PrettyName = ""
+			r.ScoreChecklist[0] = suggestedChecklistItem{Key: "Bad Key"}
+		}, []string{"pretty_name", "score_checklist[0].key", "score_checklist[0].question", "score_checklist[0].weight"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			response := valid()
+			c.edit(&response)
+			err := validateSuggestedView(suggestViewRequest{}, response)
+			if c.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("validateSuggestedView() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateSuggestedView() error = nil, want problems %q", c.wantProblems)
+			}
+			if got := strings.Count(err.Error(), "\n- "); got != len(c.wantProblems) {
+				t.Errorf("error has %d problems, want %d: %v", got, len(c.wantProblems), err)
+			}
+			for _, p := range c.wantProblems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("error = %v, want it to contain %q", err, p)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestedConfigLoads(t *testing.T) {
+	const response = `{
+		"pretty_name": "Backend Engineer",
+		"score_checklist": [
+			{"key": "go_experience", "question": "Has the candidate used Go?", "weight": 3, "important": true},
+			{"key": "degree", "question": "Does the candidate have a degree?", "weight": 0.5, "important": false}
+		],
+		"specific_questions": [{"key": "notice_period", "question": "What is their notice period?"}]
+	}`
+	model := fakeModel{respond: func(string) (string, error) { return response, nil }}
+	view, err := SuggestView(slog.New(slog.NewTextHandler(io.Discard, nil)), fakeModelBuilder{model}, "We are hiring a backend engineer.")
+	if err != nil {
+		t.Fatalf("SuggestView() error = %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "jd.txt"), []byte("We are hiring a backend engineer."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	view.JobDescriptionFile = "jd.txt"
+	data, err := suggestedConfigJSON(Config{Views: map[string]ConfigView{"backend": view}})
+	if err != nil {
+		t.Fatalf("suggestedConfigJSON() error = %v", err)
+	}
+	configPath := filepath.Join(dir, "suggested_config.json")
+	if err := os.WriteFile(configPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v for the suggested config:\n%s", err, data)
+	}
+	loaded := cfg.Views["backend"]
+	if loaded.PrettyName != "Backend Engineer" {
+		t.Errorf("pretty name = %q, want %q", loaded.PrettyName, "Backend Engineer")
+	}
+	if len(loaded.ScoreChecklist) != len(view.ScoreChecklist) {
+		t.Errorf("checklist = %+v, want %+v", loaded.ScoreChecklist, view.ScoreChecklist)
+	}
+	for key, want := range view.ScoreChecklist {
+		got := loaded.ScoreChecklist[key]
+		if got.Question != want.Question || got.Weight != want.Weight || got.Important != want.Important {
+			t.Errorf("checklist item %s = %+v, want %+v", key, got, want)
+		}
+	}
+	if got := loaded.SpecificQuestions["notice_period"].Question; len(loaded.SpecificQuestions) != 1 || got != "What is their notice period?" {
+		t.Errorf("specific questions = %+v, want the suggested question", loaded.SpecificQuestions)
+	}
+}
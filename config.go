@@ -3,23 +3,43 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
 type ConfigSpecificQuestion struct {
 	Question string `json:"question"`
+	// UseOriginalText answers the question from the resume before anonymisation.
+	UseOriginalText bool `json:"use_original_text,omitempty"`
+	// Choices, if set, makes this a multiple-choice question.
+	Choices []string `json:"choices,omitempty"`
 }
 
 type ConfigScoreChecklistItem struct {
 	Question  string
 	Weight    float64
 	Important bool
+	// Type is the kind of answer the item asks for, which is boolean unless set.
+	Type ChecklistItemType
+	// Aggregate is how the answers of number and rating items are combined across repeats.
+	Aggregate ChecklistAggregate
+	// Scoring turns the answer of a number or rating item into a score.
+	Scoring *ConfigChecklistScoring
 }
 
 type configScoreChecklistItemDTO struct {
-	Question  string   `json:"question"`
-	Weight    *float64 `json:"weight,omitempty"`
-	Important bool     `json:"important,omitempty"`
+	Question  string                  `json:"question"`
+	Weight    *float64                `json:"weight,omitempty"`
+	Important bool                    `json:"important,omitempty"`
+	Type      ChecklistItemType       `json:"type,omitempty"`
+	Aggregate ChecklistAggregate      `json:"aggregate,omitempty"`
+	Scoring   *ConfigChecklistScoring `json:"scoring,omitempty"`
 }
 
 func (c *ConfigScoreChecklistItem) UnmarshalJSON(data []byte) error {
@@ -34,11 +54,24 @@ func (c *ConfigScoreChecklistItem) UnmarshalJSON(data []byte) error {
 	}
 	c.Question = dto.Question
 	c.Important = dto.Important
+	c.Type = dto.Type
+	if c.Type == "" {
+		c.Type = ChecklistBoolean
+	}
+	c.Aggregate = dto.Aggregate
+	if c.Aggregate == "" && c.Type.IsNumeric() {
+		c.Aggregate = AggregateMean
+	}
+	c.Scoring = dto.Scoring
 
 	return nil
 }
 
-func (c *ConfigScoreChecklistItem) MarshalJSON() ([]byte, error) {
+func (c *ConfigScoreChecklistItem) configShape() any {
+	return configScoreChecklistItemDTO{}
+}
+
+func (c ConfigScoreChecklistItem) MarshalJSON() ([]byte, error) {
 	var w *float64
 	if c.Weight != 1 {
 		w = &c.Weight
@@ -47,30 +80,314 @@ func (c *ConfigScoreChecklistItem) MarshalJSON() ([]byte, error) {
 		Question:  c.Question,
 		Weight:    w,
 		Important: c.Important,
+		Aggregate: c.Aggregate,
+		Scoring:   c.Scoring,
+	}
+	if c.Type != ChecklistBoolean {
+		dto.Type = c.Type
+	}
+	if c.Aggregate == AggregateMean {
+		dto.Aggregate = ""
 	}
 	return json.Marshal(dto)
 }
 
+// ConfigTemplates optionally points a view at custom prompt template files.
+type ConfigTemplates struct {
+	Review     string `json:"review,omitempty"`
+	Questions  string `json:"questions,omitempty"`
+	Comparison string `json:"comparison,omitempty"`
+
+	reviewText     string
+	questionsText  string
+	comparisonText string
+}
+
+// ConfigTournament reranks a view's top candidates with pairwise comparisons.
+type ConfigTournament struct {
+	// TopN is how many of the top ranked candidates take part.
+	TopN int `json:"top_n"`
+}
+
 type ConfigView struct {
 	PrettyName        string                              `json:"pretty_name"`
 	ScoreChecklist    map[string]ConfigScoreChecklistItem `json:"score_checklist"`
 	SpecificQuestions map[string]ConfigSpecificQuestion   `json:"specific_questions"`
+	Templates         ConfigTemplates                     `json:"templates,omitzero"`
+	// JobDescription is the job description text, or a path to a file containing it.
+	JobDescription string `json:"job_description,omitempty"`
+	// JobDescriptionFile is a path to a file containing the job description.
+	JobDescriptionFile string `json:"job_description_file,omitempty"`
+	// Include lists groups whose items are added to this view.
+	Include []string `json:"include,omitempty"`
+	// Overrides changes the weight or importance of checklist items.
+	Overrides map[string]ConfigChecklistOverride `json:"overrides,omitempty"`
+	// Tournament, if set, reranks the top candidates after review.
+	Tournament *ConfigTournament `json:"tournament,omitempty"`
+
+	jobDescriptionText string
+}
+
+// ViewPrompts holds the per-view settings that shape the prompts sent to the model.
+type ViewPrompts struct {
+	// ReviewTemplate is the template for checklist reviews, or empty for the built-in template.
+	ReviewTemplate string
+	// QuestionTemplate is the template for specific questions, or empty for the built-in template.
+	QuestionTemplate string
+	// ComparisonTemplate is the template for tournament comparisons.
+	ComparisonTemplate string
+	// JobDescription is rendered into every prompt, so changing it also invalidates cached responses.
+	JobDescription string
+}
+
+// hasChoiceQuestions returns true if any of the view's questions are multiple-choice.
+func (v ConfigView) hasChoiceQuestions() bool {
+	for _, q := range v.SpecificQuestions {
+		if len(q.Choices) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Prompts returns the prompt settings of the view.
+func (v ConfigView) Prompts() ViewPrompts {
+	return ViewPrompts{
+		ReviewTemplate:     v.Templates.reviewText,
+		QuestionTemplate:   v.Templates.questionsText,
+		ComparisonTemplate: v.Templates.comparisonText,
+		JobDescription:     v.jobDescriptionText,
+	}
 }
 
 type Config struct {
-	Views map[string]ConfigView `json:"views"`
+	// PIIRedaction lists the categories of PII that are redacted before review.
+	PIIRedaction []PIICategory `json:"pii_redaction,omitempty"`
+	// Anonymise removes attributes such as names and ages from resumes before review.
+	Anonymise bool `json:"anonymise,omitempty"`
+	// Profile, if set, extracts a structured profile from every CV.
+	Profile *ConfigProfile         `json:"profile,omitempty"`
+	Groups  map[string]ConfigGroup `json:"groups,omitempty"`
+	Views   map[string]ConfigView  `json:"views"`
+}
+
+// ConfigProfile turns on profile extraction, which costs one extra LLM call per candidate.
+type ConfigProfile struct {
+	// ReportColumns are the profile fields added to every view's reports.
+	ReportColumns []string `json:"report_columns,omitempty"`
+}
+
+// ProfileReportColumns returns the profile fields that are added to reports.
+func (c Config) ProfileReportColumns() []string {
+	if c.Profile == nil {
+		return nil
+	}
+	return c.Profile.ReportColumns
+}
+
+// configFileNames are the config files that FindConfigFile looks for, in order of preference.
+var configFileNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// FindConfigFile returns the path of the JSON, YAML or TOML config file in dir.
+func FindConfigFile(dir string) (string, error) {
+	found := make([]string, 0)
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no config file found, expected one of %v", configFileNames)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("found more than one config file, only one of %v may exist", found)
+	}
+}
+
+// LoadConfig reads, decodes and validates the config file at configPath.
+func LoadConfig(configPath string) (Config, error) {
+	data, err := ReadConfigAsJSON(configPath)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfigJSON(data, filepath.Dir(configPath))
+}
+
+// ReadConfigAsJSON reads the config file at configPath as JSON, without decoding it.
+func ReadConfigAsJSON(configPath string) ([]byte, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read config file"), err)
+	}
+	data, err = configToJSON(filepath.Ext(configPath), data)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to parse config file"), err)
+	}
+	return data, nil
 }
 
-func LoadConfig() (Config, error) {
-	f, err := os.Open("./config.json")
+// ParseConfigJSON decodes and validates a JSON config relative to dir.
+func ParseConfigJSON(data []byte, dir string) (Config, error) {
+	cfg, err := decodeConfigStrict[Config](data)
 	if err != nil {
-		return Config{}, errors.Join(errors.New("failed to read config file"), err)
+		return Config{}, errors.Join(errors.New("failed to parse config file"), err)
 	}
-	defer f.Close()
-	cfg := Config{}
-	err = json.NewDecoder(f).Decode(&cfg)
+	cfg, err = ResolveIncludes(cfg, dir)
 	if err != nil {
-		return Config{}, errors.Join(errors.New("failed to parse config fike"), err)
+		return Config{}, errors.Join(errors.New("failed to resolve includes"), err)
+	}
+	if err := ValidateConfig(cfg); err != nil {
+		return Config{}, errors.Join(errors.New("invalid config file"), err)
+	}
+	for name, view := range cfg.Views {
+		viewPath := joinConfigPath("views", name)
+		view.jobDescriptionText, err = loadJobDescription(dir, view.JobDescription)
+		if err != nil {
+			return Config{}, &ConfigError{Path: joinConfigPath(viewPath, "job_description"), Message: err.Error()}
+		}
+		if view.JobDescriptionFile != "" {
+			view.jobDescriptionText, err = readJobDescriptionFile(filepath.Join(dir, view.JobDescriptionFile))
+			if err != nil {
+				return Config{}, &ConfigError{Path: joinConfigPath(viewPath, "job_description_file"), Message: err.Error()}
+			}
+		}
+		view.Templates, err = loadConfigTemplates(dir, view.Templates, view.hasChoiceQuestions(), view.jobDescriptionText != "")
+		if err != nil {
+			return Config{}, &ConfigError{Path: joinConfigPath(viewPath, "templates"), Message: err.Error()}
+		}
+		cfg.Views[name] = view
 	}
 	return cfg, nil
 }
+
+// configToJSON converts a YAML or TOML config to the equivalent JSON.
+func configToJSON(ext string, data []byte) ([]byte, error) {
+	var generic any
+	switch strings.ToLower(ext) {
+	case ".json":
+		return data, nil
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q", ext)
+	}
+	return json.Marshal(stringifyKeys(generic))
+}
+
+// stringifyKeys converts maps with non-string keys into maps with string keys.
+func stringifyKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = stringifyKeys(e)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = stringifyKeys(e)
+		}
+		return m
+	case []any:
+		for i, e := range v {
+			v[i] = stringifyKeys(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// loadConfigTemplates reads and validates the template files of tmpls, relative to dir.
+func loadConfigTemplates(dir string, tmpls ConfigTemplates, repeatedQuestions bool, hasJobDescription bool) (ConfigTemplates, error) {
+	if tmpls.Review != "" {
+		text, err := os.ReadFile(filepath.Join(dir, tmpls.Review))
+		if err != nil {
+			return tmpls, err
+		}
+		if err := ValidateReviewTemplate(string(text), hasJobDescription); err != nil {
+			return tmpls, errors.Join(fmt.Errorf("invalid review template %s", tmpls.Review), err)
+		}
+		tmpls.reviewText = string(text)
+	}
+	if tmpls.Questions != "" {
+		text, err := os.ReadFile(filepath.Join(dir, tmpls.Questions))
+		if err != nil {
+			return tmpls, err
+		}
+		if err := ValidateQuestionTemplate(string(text), repeatedQuestions, hasJobDescription); err != nil {
+			return tmpls, errors.Join(fmt.Errorf("invalid questions template %s", tmpls.Questions), err)
+		}
+		tmpls.questionsText = string(text)
+	}
+	if tmpls.Comparison != "" {
+		text, err := os.ReadFile(filepath.Join(dir, tmpls.Comparison))
+		if err != nil {
+			return tmpls, err
+		}
+		if err := ValidateComparisonTemplate(string(text), hasJobDescription); err != nil {
+			return tmpls, errors.Join(fmt.Errorf("invalid comparison template %s", tmpls.Comparison), err)
+		}
+		tmpls.comparisonText = string(text)
+	}
+	return tmpls, nil
+}
+
+// jobDescriptionFileExts are the extensions of job description files.
+var jobDescriptionFileExts = []string{".txt", ".md", ".pdf"}
+
+// looksLikeJobDescriptionPath returns true if jobDesc looks like a path rather than text.
+func looksLikeJobDescriptionPath(jobDesc string) bool {
+	if jobDesc == "" || strings.ContainsAny(jobDesc, "\n") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(jobDesc))
+	if slices.Contains(jobDescriptionFileExts, ext) {
+		return true
+	}
+	if strings.ContainsAny(jobDesc, " \t") {
+		return false
+	}
+	return strings.ContainsAny(jobDesc, `/\`) || len(ext) > 1
+}
+
+// isJobDescriptionFile returns true if jobDesc is the path of a file relative to dir.
+func isJobDescriptionFile(dir string, jobDesc string) bool {
+	if jobDesc == "" || strings.ContainsAny(jobDesc, "\n") {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(dir, jobDesc))
+	return err == nil && !info.IsDir()
+}
+
+// loadJobDescription returns the job description text, reading it from a file if needed.
+func loadJobDescription(dir string, jobDesc string) (string, error) {
+	if isJobDescriptionFile(dir, jobDesc) {
+		return readJobDescriptionFile(filepath.Join(dir, jobDesc))
+	}
+	if looksLikeJobDescriptionPath(jobDesc) {
+		return "", fmt.Errorf("job description file %s does not exist, write the job description as text if it is not a path", jobDesc)
+	}
+	return jobDesc, nil
+}
+
+// readJobDescriptionFile reads the job description from a file, extracting the text if it is a PDF.
+func readJobDescriptionFile(path string) (string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		return GetTextFromPDFFile(path)
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
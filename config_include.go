@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ConfigGroup is a reusable set of checklist items and specific questions that views can include.
+type ConfigGroup struct {
+	ScoreChecklist    map[string]ConfigScoreChecklistItem `json:"score_checklist,omitempty"`
+	SpecificQuestions map[string]ConfigSpecificQuestion   `json:"specific_questions,omitempty"`
+}
+
+// ConfigChecklistOverride changes the weight or importance of a checklist item in one view.
+type ConfigChecklistOverride struct {
+	Weight    *float64 `json:"weight,omitempty"`
+	Important *bool    `json:"important,omitempty"`
+}
+
+// ResolveIncludes copies included group items into the views, then applies overrides.
+func ResolveIncludes(cfg Config, dir string) (Config, error) {
+	errs := make([]error, 0)
+	fileGroups := make(map[string]ConfigGroup)
+	views := make(map[string]ConfigView, len(cfg.Views))
+	for _, viewName := range slices.Sorted(maps.Keys(cfg.Views)) {
+		view := cfg.Views[viewName]
+		viewPath := joinConfigPath("views", viewName)
+		// Track where each key came from, so that conflicts can be explained.
+		keySources := make(map[string]string)
+		checklist := make(map[string]ConfigScoreChecklistItem)
+		questions := make(map[string]ConfigSpecificQuestion)
+		for k, v := range view.ScoreChecklist {
+			checklist[k] = v
+			keySources[k] = "the view itself"
+		}
+		for k, v := range view.SpecificQuestions {
+			questions[k] = v
+			keySources[k] = "the view itself"
+		}
+		for i, include := range view.Include {
+			includePath := fmt.Sprintf("%s[%d]", joinConfigPath(viewPath, "include"), i)
+			group, err := findGroup(cfg, dir, include, fileGroups)
+			if err != nil {
+				errs = append(errs, &ConfigError{Path: includePath, Message: err.Error()})
+				continue
+			}
+			addFromGroup := func(key string) bool {
+				if source, ok := keySources[key]; ok {
+					errs = append(errs, &ConfigError{Path: includePath, Message: fmt.Sprintf("key %q from %q is already defined by %s", key, include, source)})
+					return false
+				}
+				keySources[key] = fmt.Sprintf("%q", include)
+				return true
+			}
+			for _, k := range slices.Sorted(maps.Keys(group.ScoreChecklist)) {
+				if addFromGroup(k) {
+					checklist[k] = group.ScoreChecklist[k]
+				}
+			}
+			for _, k := range slices.Sorted(maps.Keys(group.SpecificQuestions)) {
+				if addFromGroup(k) {
+					questions[k] = group.SpecificQuestions[k]
+				}
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(view.Overrides)) {
+			override := view.Overrides[key]
+			item, ok := checklist[key]
+			if !ok {
+				errs = append(errs, &ConfigError{Path: joinConfigPath(viewPath, "overrides", key), Message: "there is no checklist item with this key to override"})
+				continue
+			}
+			if override.Weight != nil {
+				item.Weight = *override.Weight
+			}
+			if override.Important != nil {
+				item.Important = *override.Important
+			}
+			checklist[key] = item
+		}
+		view.ScoreChecklist = checklist
+		view.SpecificQuestions = questions
+		view.Include = nil
+		view.Overrides = nil
+		views[viewName] = view
+	}
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+	cfg.Views = views
+	return cfg, nil
+}
+
+// isGroupFile returns true if the include refers to a group file rather than a named group.
+func isGroupFile(include string) bool {
+	return slices.Contains([]string{".json", ".yaml", ".yml", ".toml"}, strings.ToLower(filepath.Ext(include)))
+}
+
+// findGroup looks up an included group by name, or loads it from a file.
+func findGroup(cfg Config, dir string, include string, fileGroups map[string]ConfigGroup) (ConfigGroup, error) {
+	if !isGroupFile(include) {
+		group, ok := cfg.Groups[include]
+		if !ok {
+			return ConfigGroup{}, fmt.Errorf("there is no group called %q", include)
+		}
+		return group, nil
+	}
+	if group, ok := fileGroups[include]; ok {
+		return group, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, include))
+	if err != nil {
+		return ConfigGroup{}, err
+	}
+	data, err = configToJSON(filepath.Ext(include), data)
+	if err != nil {
+		return ConfigGroup{}, errors.Join(fmt.Errorf("failed to parse group file %s", include), err)
+	}
+	group, err := decodeConfigStrict[ConfigGroup](data)
+	if err != nil {
+		return ConfigGroup{}, errors.Join(fmt.Errorf("invalid group file %s", include), err)
+	}
+	validationErrs := make([]error, 0)
+	add := func(path string, format string, args ...any) {
+		validationErrs = append(validationErrs, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	for k, item := range group.ScoreChecklist {
+		validateChecklistItem(joinConfigPath("score_checklist", k), item, add)
+	}
+	for k, q := range group.SpecificQuestions {
+		validateSpecificQuestion(joinConfigPath("specific_questions", k), q, add)
+	}
+	if len(validationErrs) > 0 {
+		return ConfigGroup{}, errors.Join(append([]error{fmt.Errorf("invalid group file %s", include)}, validationErrs...)...)
+	}
+	fileGroups[include] = group
+	return group, nil
+}
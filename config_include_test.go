@@ -0,0 +1,145 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"languages.yaml": "score_checklist:\n  go: {question: \"Does the candidate know Go?\"}\n",
+		"broken.yaml":    "score_checklist: [unclosed\n",
+		"invalid.json":   `{"score_checklist": {"go": {"question": " "}}}`,
+		"unknown.json":   `{"score_checklist": {"go": {"questoin": "Go?"}}}`,
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const groups = `"groups": {
+		"basics": {"score_checklist": {"degree": {"question": "Does the candidate have a degree?", "weight": 2}}, "specific_questions": {"name": {"question": "What is their name?"}}},
+		"more_basics": {"score_checklist": {"degree": {"question": "Does the candidate have a degree?"}}}
+	}`
+	cases := []struct {
+		name          string
+		view          string
+		wantChecklist map[string]ConfigScoreChecklistItem
+		wantQuestions []string
+		wantPaths     []string
+	}{
+		{
+			name: "named group",
+			view: `"include": ["basics"], "score_checklist": {"sql": {"question": "Does the candidate know SQL?"}}`,
+			wantChecklist: map[string]ConfigScoreChecklistItem{
+				"degree": {Question: "Does the candidate have a degree?", Weight: 2},
+				"sql":    {Question: "Does the candidate know SQL?", Weight: 1},
+			},
+			wantQuestions: []string{"name"},
+		},
+		{
+			name: "group file",
+			view: `"include": ["languages.yaml"]`,
+			wantChecklist: map[string]ConfigScoreChecklistItem{
+				"go": {Question: "Does the candidate know Go?", Weight: 1},
+			},
+		},
+		{
+			name: "overrides",
+			view: `"include": ["basics", "languages.yaml"], "overrides": {"degree": {"important": true}, "go": {"weight": 3}}`,
+			wantChecklist: map[string]ConfigScoreChecklistItem{
+				"degree": {Question: "Does the candidate have a degree?", Weight: 2, Important: true},
+				"go":     {Question: "Does the candidate know Go?", Weight: 3},
+			},
+			wantQuestions: []string{"name"},
+		},
+		{
+			name:      "conflict between groups",
+			view:      `"include": ["basics", "more_basics"]`,
+			wantPaths: []string{"views.dev.include[1]"},
+		},
+		{
+			name:      "conflict with the view",
+			view:      `"include": ["languages.yaml"], "score_checklist": {"go": {"question": "Go?"}}`,
+			wantPaths: []string{"views.dev.include[0]"},
+		},
+		{
+			name:      "override of a missing key",
+			view:      `"include": ["basics"], "overrides": {"rust": {"weight": 2}}`,
+			wantPaths: []string{"views.dev.overrides.rust"},
+		},
+		{
+			name:      "missing group",
+			view:      `"include": ["basic"]`,
+			wantPaths: []string{"views.dev.include[0]"},
+		},
+		{
+			name:      "missing group file",
+			view:      `"include": ["missing.yaml", "basics"]`,
+			wantPaths: []string{"views.dev.include[0]"},
+		},
+		{
+			name:      "unparseable group file",
+			view:      `"include": ["broken.yaml"]`,
+			wantPaths: []string{"views.dev.include[0]"},
+		},
+		{
+			name:      "invalid group file",
+			view:      `"include": ["invalid.json", "unknown.json"]`,
+			wantPaths: []string{"views.dev.include[0]", "views.dev.include[1]"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg, err := decodeConfigStrict[Config]([]byte(`{` + groups + `, "views": {"dev": {` + c.view + `}}}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resolved, err := ResolveIncludes(cfg, dir)
+			if c.wantPaths != nil {
+				paths := make([]string, 0)
+				for _, e := range ConfigErrors(err) {
+					paths = append(paths, e.Path)
+				}
+				if !slices.Equal(paths, c.wantPaths) {
+					t.Errorf("error paths = %q, want %q (%v)", paths, c.wantPaths, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveIncludes() error = %v", err)
+			}
+			view := resolved.Views["dev"]
+			if !maps.EqualFunc(view.ScoreChecklist, c.wantChecklist, func(a, b ConfigScoreChecklistItem) bool {
+				return a.Question == b.Question && a.Weight == b.Weight && a.Important == b.Important
+			}) {
+				t.Errorf("checklist = %+v, want %+v", view.ScoreChecklist, c.wantChecklist)
+			}
+			if got := slices.Sorted(maps.Keys(view.SpecificQuestions)); !slices.Equal(got, c.wantQuestions) {
+				t.Errorf("questions = %q, want %q", got, c.wantQuestions)
+			}
+			if view.Include != nil || view.Overrides != nil {
+				t.Errorf("resolved view still has includes %q or overrides %v", view.Include, view.Overrides)
+			}
+		})
+	}
+}
+
+func TestResolveIncludesErrorMessages(t *testing.T) {
+	cfg, err := decodeConfigStrict[Config]([]byte(`{
+		"groups": {"a": {"score_checklist": {"go": {"question": "Go?"}}}, "b": {"score_checklist": {"go": {"question": "Go?"}}}},
+		"views": {"dev": {"include": ["a", "b"]}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ResolveIncludes(cfg, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), `key "go" from "b" is already defined by "a"`) {
+		t.Errorf("ResolveIncludes() error = %v, want it to say which group already defined the key", err)
+	}
+}
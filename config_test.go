@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadJobDescription(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "backend.txt"), []byte("Build our payments API."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		jobDesc string
+		want    string
+	}{
+		{"empty", "", ""},
+		{"file", "backend.txt", "Build our payments API."},
+		{"inline text", "Senior accountant at a Big Four firm.", "Senior accountant at a Big Four firm."},
+		{"inline text with slashes", "Senior CI/CD engineer, TCP/IP", "Senior CI/CD engineer, TCP/IP"},
+		{"multi-line text", "Backend engineer\nGo, Postgres", "Backend engineer\nGo, Postgres"},
+		{"sentence ending in a full stop", "Go developer.", "Go developer."},
+		{"sentence mentioning a file type", "Frontend developer using Node.js and React", "Frontend developer using Node.js and React"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := loadJobDescription(dir, c.jobDesc)
+			if err != nil {
+				t.Fatalf("loadJobDescription(%q) error = %v", c.jobDesc, err)
+			}
+			if got != c.want {
+				t.Errorf("loadJobDescription(%q) = %q, want %q", c.jobDesc, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLoadJobDescriptionMissingFile(t *testing.T) {
+	for _, jobDesc := range []string{"backend.pdf", "jobs/backend", "job descriptions/backend.txt", "backend.docx"} {
+		if _, err := loadJobDescription(t.TempDir(), jobDesc); err == nil {
+			t.Errorf("loadJobDescription(%q) error = nil, want an error as the file does not exist", jobDesc)
+		}
+	}
+}
+
+func TestJobDescriptionNeedsTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "review.tmpl"), []byte("{{ .Resume }} {{ .Checklist }} {{ .RepeatNumber }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const view = `"score_checklist": {"go": {"question": "Does the candidate know Go?"}}, "templates": {"review": "review.tmpl"}`
+	if _, err := ParseConfigJSON([]byte(`{"views": {"dev": {`+view+`}}}`), dir); err != nil {
+		t.Fatalf("ParseConfigJSON() without a job description error = %v", err)
+	}
+	_, err := ParseConfigJSON([]byte(`{"views": {"dev": {`+view+`, "job_description": "Build APIs."}}}`), dir)
+	if errs := ConfigErrors(err); err == nil || errs[0].Path != "views.dev.templates" {
+		t.Errorf("ParseConfigJSON() with a job description error = %v, want an error at views.dev.templates", err)
+	}
+}
+
+func TestJobDescriptionFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "backend.txt"), []byte("Build our payments API."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const view = `"score_checklist": {"go": {"question": "Does the candidate know Go?"}}`
+	cfg, err := ParseConfigJSON([]byte(`{"views": {"dev": {`+view+`, "job_description_file": "backend.txt"}}}`), dir)
+	if err != nil {
+		t.Fatalf("ParseConfigJSON() error = %v", err)
+	}
+	if got := cfg.Views["dev"].Prompts().JobDescription; got != "Build our payments API." {
+		t.Errorf("job description = %q, want the file's text", got)
+	}
+	_, err = ParseConfigJSON([]byte(`{"views": {"dev": {`+view+`, "job_description_file": "backend.pdf"}}}`), dir)
+	if errs := ConfigErrors(err); err == nil || errs[0].Path != "views.dev.job_description_file" {
+		t.Errorf("ParseConfigJSON() with a missing file error = %v, want an error at views.dev.job_description_file", err)
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	cases := []struct {
+		name   string
+		file   string
+		config string
+	}{
+		{"json", "config.json", `{"views": {"dev": {"score_checklist": {"go": {"question": "Does the candidate know Go?"}, "sql": {"question": "Does the candidate know SQL?", "weight": 2}}}}}`},
+		{"yaml", "config.yaml", "views:\n  dev:\n    score_checklist:\n      go:\n        question: Does the candidate know Go?\n      sql:\n        question: Does the candidate know SQL?\n        weight: 2\n"},
+		{"yml", "config.yml", "views:\n  dev:\n    score_checklist:\n      go: {question: \"Does the candidate know Go?\"}\n      sql: {question: \"Does the candidate know SQL?\", weight: 2}\n"},
+		{"toml", "config.toml", "[views.dev.score_checklist.go]\nquestion = \"Does the candidate know Go?\"\n\n[views.dev.score_checklist.sql]\nquestion = \"Does the candidate know SQL?\"\nweight = 2\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.file)
+			if err := os.WriteFile(path, []byte(c.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			checklist := cfg.Views["dev"].ScoreChecklist
+			if got := checklist["go"]; got.Question != "Does the candidate know Go?" || got.Weight != 1 || got.Type != ChecklistBoolean {
+				t.Errorf("go item = %+v, want the question with the default weight of 1", got)
+			}
+			if got := checklist["sql"].Weight; got != 2 {
+				t.Errorf("sql weight = %v, want 2", got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigNonStringKeys(t *testing.T) {
+	// YAML reads unquoted keys such as 1 and true as numbers and booleans, which must still be usable as checklist keys.
+	const config = "views:\n  dev:\n    score_checklist:\n      1: {question: \"Does the candidate have a degree?\"}\n      true: {question: \"Does the candidate know Go?\"}\n"
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	checklist := cfg.Views["dev"].ScoreChecklist
+	if checklist["1"].Question != "Does the candidate have a degree?" || checklist["true"].Question != "Does the candidate know Go?" {
+		t.Errorf("checklist = %+v, want items keyed by \"1\" and \"true\"", checklist)
+	}
+}
+
+func TestStringifyKeys(t *testing.T) {
+	got := stringifyKeys(map[string]any{
+		"views": map[any]any{1: []any{map[any]any{true: "yes"}}, "dev": 2.5},
+	})
+	want := map[string]any{
+		"views": map[string]any{"1": []any{map[string]any{"true": "yes"}}, "dev": 2.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stringifyKeys() = %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadConfigParseErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		file   string
+		config string
+	}{
+		{"invalid yaml", "config.yaml", "views:\n  dev: [unclosed\n"},
+		{"invalid toml", "config.toml", "[views.dev\nquestion = 1\n"},
+		{"unknown yaml field", "config.yaml", "views:\n  dev:\n    score_checklist:\n      go: {questoin: Go?}\n"},
+		{"mistyped toml field", "config.toml", "[views.dev.score_checklist.go]\nquestion = \"Go?\"\nweight = \"2\"\n"},
+		{"unsupported extension", "config.ini", "views = {}"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.file)
+			if err := os.WriteFile(path, []byte(c.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadConfig(path); err == nil {
+				t.Errorf("LoadConfig() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ConfigError describes a single problem with a config, at a JSON path.
+type ConfigError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ConfigErrors returns every *ConfigError within err.
+func ConfigErrors(err error) []*ConfigError {
+	errs := make([]*ConfigError, 0)
+	var collect func(error)
+	collect = func(e error) {
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			for _, inner := range joined.Unwrap() {
+				collect(inner)
+			}
+		} else if cfgErr, ok := e.(*ConfigError); ok {
+			errs = append(errs, cfgErr)
+		}
+	}
+	collect(err)
+	if len(errs) == 0 {
+		return []*ConfigError{{Message: err.Error()}}
+	}
+	return errs
+}
+
+// reservedReportColumns are the CSV report columns that keys cannot use.
+var reservedReportColumns = []string{"candidate_id", "file_name", "file_loc", "duplicates", "final_score", "tournament_rank", "tournament_score"}
+
+// safeKeyPattern matches keys that can safely be used as CSV headers and in file names.
+var safeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateConfig checks that a decoded config makes sense, returning every problem found.
+func ValidateConfig(cfg Config) error {
+	errs := make([]error, 0)
+	add := func(path string, format string, args ...any) {
+		errs = append(errs, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if len(cfg.Views) == 0 {
+		add("views", "at least one view must be defined")
+	}
+	for i, category := range cfg.PIIRedaction {
+		if !slices.Contains(piiCategories, category) {
+			add(fmt.Sprintf("pii_redaction[%d]", i), "unknown PII category %q, expected one of %v", category, piiCategories)
+		}
+	}
+	if cfg.Profile != nil {
+		for i, column := range cfg.Profile.ReportColumns {
+			if _, ok := profileColumns[column]; !ok {
+				add(fmt.Sprintf("profile.report_columns[%d]", i), "unknown profile field %q, expected one of %v", column, slices.Sorted(maps.Keys(profileColumns)))
+			} else if slices.Index(cfg.Profile.ReportColumns, column) < i {
+				add(fmt.Sprintf("profile.report_columns[%d]", i), "profile field %q is listed more than once", column)
+			}
+		}
+	}
+	for _, groupName := range slices.Sorted(maps.Keys(cfg.Groups)) {
+		group := cfg.Groups[groupName]
+		groupPath := joinConfigPath("groups", groupName)
+		for _, key := range slices.Sorted(maps.Keys(group.ScoreChecklist)) {
+			validateChecklistItem(joinConfigPath(groupPath, "score_checklist", key), group.ScoreChecklist[key], add)
+		}
+		for _, key := range slices.Sorted(maps.Keys(group.SpecificQuestions)) {
+			validateSpecificQuestion(joinConfigPath(groupPath, "specific_questions", key), group.SpecificQuestions[key], add)
+		}
+	}
+	for _, viewName := range slices.Sorted(maps.Keys(cfg.Views)) {
+		view := cfg.Views[viewName]
+		viewPath := joinConfigPath("views", viewName)
+		if !safeKeyPattern.MatchString(viewName) {
+			add(viewPath, "view name must only contain letters, digits, underscores and dashes, as it is used in file names")
+		}
+		if len(view.ScoreChecklist) == 0 {
+			add(joinConfigPath(viewPath, "score_checklist"), "at least one checklist item must be defined")
+		}
+		columnOwners := make(map[string]string)
+		checkKey := func(path string, key string) {
+			if !safeKeyPattern.MatchString(key) {
+				add(path, "key must only contain letters, digits, underscores and dashes, as it is used as a CSV header")
+			} else if slices.Contains(reservedReportColumns, key) || isProfileReportColumn(key) {
+				add(path, "key %q is reserved for a report column", key)
+			} else if owner, ok := columnOwners[key]; ok {
+				add(path, "key %q is already used by %s, keys must be unique across the checklist and questions", key, owner)
+			}
+			columnOwners[key] = path
+		}
+		for _, key := range slices.Sorted(maps.Keys(view.ScoreChecklist)) {
+			item := view.ScoreChecklist[key]
+			itemPath := joinConfigPath(viewPath, "score_checklist", key)
+			checkKey(itemPath, key)
+			validateChecklistItem(itemPath, item, add)
+		}
+		for _, key := range slices.Sorted(maps.Keys(view.SpecificQuestions)) {
+			q := view.SpecificQuestions[key]
+			qPath := joinConfigPath(viewPath, "specific_questions", key)
+			checkKey(qPath, key)
+			validateSpecificQuestion(qPath, q, add)
+			if q.UseOriginalText && !cfg.Anonymise {
+				add(joinConfigPath(qPath, "use_original_text"), "use_original_text has no effect unless anonymise is enabled")
+			}
+		}
+		if view.JobDescription != "" && view.JobDescriptionFile != "" {
+			add(joinConfigPath(viewPath, "job_description_file"), "only one of job_description and job_description_file can be set")
+		}
+		if view.Tournament != nil {
+			if view.Tournament.TopN < 2 {
+				add(joinConfigPath(viewPath, "tournament", "top_n"), "top_n must be at least 2, as candidates are compared in pairs")
+			} else if view.Tournament.TopN > maxTournamentSize {
+				add(joinConfigPath(viewPath, "tournament", "top_n"), "top_n must be at most %d, as every pair is compared and larger tournaments need too many LLM calls", maxTournamentSize)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateChecklistItem(path string, item ConfigScoreChecklistItem, add func(string, string, ...any)) {
+	if strings.TrimSpace(item.Question) == "" {
+		add(joinConfigPath(path, "question"), "question must not be empty")
+	}
+	if math.IsNaN(item.Weight) || math.IsInf(item.Weight, 0) {
+		add(joinConfigPath(path, "weight"), "weight must be a finite number")
+	}
+	if item.Type != "" && !slices.Contains(checklistItemTypes, item.Type) {
+		add(joinConfigPath(path, "type"), "unknown type %q, expected one of %v", item.Type, checklistItemTypes)
+		return
+	}
+	if !item.Type.IsNumeric() {
+		if item.Aggregate != "" {
+			add(joinConfigPath(path, "aggregate"), "aggregate only applies to number and rating items")
+		}
+		if item.Scoring != nil {
+			add(joinConfigPath(path, "scoring"), "scoring only applies to number and rating items")
+		}
+		return
+	}
+	if item.Aggregate != "" && !slices.Contains(checklistAggregates, item.Aggregate) {
+		add(joinConfigPath(path, "aggregate"), "unknown aggregate %q, expected one of %v", item.Aggregate, checklistAggregates)
+	}
+	if item.Scoring == nil {
+		if item.Type == ChecklistNumber {
+			add(joinConfigPath(path, "scoring"), "number items must have scoring, as there is no natural range for their answers")
+		}
+		return
+	}
+	scoringPath := joinConfigPath(path, "scoring")
+	switch item.Scoring.Function {
+	case "", ScoringLinear:
+		if item.Scoring.Min == item.Scoring.Max {
+			add(scoringPath, "min and max must be different for linear scoring")
+		}
+		if item.Scoring.Threshold != nil {
+			add(joinConfigPath(scoringPath, "threshold"), "threshold only applies to threshold scoring")
+		}
+	case ScoringThreshold:
+		if item.Scoring.Threshold == nil {
+			add(joinConfigPath(scoringPath, "threshold"), "threshold scoring must have a threshold")
+		}
+	default:
+		add(joinConfigPath(scoringPath, "function"), "unknown scoring function %q, expected one of %v", item.Scoring.Function, scoringFunctions)
+	}
+}
+
+func validateSpecificQuestion(path string, q ConfigSpecificQuestion, add func(string, string, ...any)) {
+	if strings.TrimSpace(q.Question) == "" {
+		add(joinConfigPath(path, "question"), "question must not be empty")
+	}
+	if q.Choices == nil {
+		return
+	}
+	if len(q.Choices) < 2 {
+		add(joinConfigPath(path, "choices"), "choices must list at least 2 answers")
+	}
+	// Answers are matched to choices ignoring case, so choices must differ by more than case.
+	seen := make(map[string]bool)
+	for i, c := range q.Choices {
+		choicePath := fmt.Sprintf("%s[%d]", joinConfigPath(path, "choices"), i)
+		normalised := strings.ToLower(strings.TrimSpace(c))
+		if normalised == "" {
+			add(choicePath, "choice must not be empty")
+		} else if seen[normalised] {
+			add(choicePath, "choice %q is listed more than once", c)
+		} else if strings.ContainsAny(c, "=;") {
+			add(choicePath, "choice must not contain = or ;, as they separate the choices in the reports")
+		}
+		seen[normalised] = true
+	}
+}
+
+// decodeConfigStrict decodes JSON config data into T, reporting problems by JSON path.
+func decodeConfigStrict[T any](data []byte) (T, error) {
+	var result T
+	checker := &configShapeChecker{dec: json.NewDecoder(strings.NewReader(string(data)))}
+	checker.dec.UseNumber()
+	if err := checker.check(reflect.TypeFor[T](), ""); err != nil {
+		return result, err
+	}
+	if _, err := checker.dec.Token(); err != io.EOF {
+		return result, &ConfigError{Message: "unexpected data after the end of the config"}
+	}
+	if len(checker.errs) > 0 {
+		return result, errors.Join(checker.errs...)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// configShaper describes the JSON accepted by config types with custom decoding.
+type configShaper interface {
+	configShape() any
+}
+
+// configShapeChecker walks the JSON tokens of a config alongside its Go type.
+type configShapeChecker struct {
+	dec  *json.Decoder
+	errs []error
+}
+
+func (c *configShapeChecker) addErr(path string, format string, args ...any) {
+	c.errs = append(c.errs, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// check consumes one JSON value, returning only syntax errors.
+func (c *configShapeChecker) check(t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if shaper, ok := reflect.New(t).Interface().(configShaper); ok {
+		t = reflect.TypeOf(shaper.configShape())
+	}
+	tok, err := c.dec.Token()
+	if err != nil {
+		return &ConfigError{Path: path, Message: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if tok != json.Delim('{') {
+			c.addErr(path, "expected an object, got %s", describeJSONToken(tok))
+			return c.skipRest(tok)
+		}
+		fields := jsonFieldsOf(t)
+		return c.checkObject(path, func(key string) (reflect.Type, bool) {
+			f, ok := fields[key]
+			return f, ok
+		})
+	case reflect.Map:
+		if tok != json.Delim('{') {
+			c.addErr(path, "expected an object, got %s", describeJSONToken(tok))
+			return c.skipRest(tok)
+		}
+		return c.checkObject(path, func(string) (reflect.Type, bool) {
+			return t.Elem(), true
+		})
+	case reflect.Slice:
+		if tok != json.Delim('[') {
+			c.addErr(path, "expected a list, got %s", describeJSONToken(tok))
+			return c.skipRest(tok)
+		}
+		for i := 0; c.dec.More(); i++ {
+			if err := c.check(t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		_, err := c.dec.Token()
+		return err
+	case reflect.String:
+		if _, ok := tok.(string); !ok {
+			c.addErr(path, "expected a string, got %s", describeJSONToken(tok))
+			return c.skipRest(tok)
+		}
+	case reflect.Bool:
+		if _, ok := tok.(bool); !ok {
+			c.addErr(path, "expected true or false, got %s", describeJSONToken(tok))
+			return c.skipRest(tok)
+		}
+	case reflect.Float32, reflect.Float64, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, ok := tok.(json.Number); !ok {
+			c.addErr(path, "expected a number, got %s", describeJSONToken(tok))
+			return c.skipRest(tok)
+		}
+	default:
+		return c.skipRest(tok)
+	}
+	return nil
+}
+
+// checkObject consumes the keys and values of an object whose opening brace has already been read.
+func (c *configShapeChecker) checkObject(path string, fieldType func(string) (reflect.Type, bool)) error {
+	seen := make(map[string]bool)
+	for c.dec.More() {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return &ConfigError{Path: path, Message: fmt.Sprintf("invalid JSON: %v", err)}
+		}
+		key := tok.(string)
+		keyPath := joinConfigPath(path, key)
+		if seen[key] {
+			c.addErr(keyPath, "duplicate key %q", key)
+		}
+		seen[key] = true
+		ft, ok := fieldType(key)
+		if !ok {
+			c.addErr(keyPath, "unknown field %q", key)
+			if err := c.skipValue(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.check(ft, keyPath); err != nil {
+			return err
+		}
+	}
+	_, err := c.dec.Token()
+	return err
+}
+
+// skipValue consumes a whole JSON value without checking it.
+func (c *configShapeChecker) skipValue() error {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	return c.skipRest(tok)
+}
+
+// skipRest consumes the remainder of a JSON value whose first token has already been read.
+func (c *configShapeChecker) skipRest(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// jsonFieldsOf returns the JSON field names of a struct type, mapped to their types.
+func jsonFieldsOf(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func describeJSONToken(tok json.Token) string {
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return "an object"
+		}
+		return "a list"
+	case string:
+		return fmt.Sprintf("the string %q", v)
+	case json.Number:
+		return fmt.Sprintf("the number %s", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+var plainPathSegment = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// joinConfigPath appends keys to a JSON path, quoting any key that is not a plain identifier.
+func joinConfigPath(path string, keys ...string) string {
+	for _, k := range keys {
+		if plainPathSegment.MatchString(k) {
+			if path == "" {
+				path = k
+			} else {
+				path = path + "." + k
+			}
+		} else {
+			path = fmt.Sprintf("%s[%q]", path, k)
+		}
+	}
+	return path
+}
+
+// ValidateConfigFilesWithin checks that every file the config refers to is inside dir.
+func ValidateConfigFilesWithin(cfg Config, dir string) error {
+	errs := make([]error, 0)
+	check := func(path string, file string) {
+		rel, err := filepath.Rel(dir, filepath.Join(dir, file))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			errs = append(errs, &ConfigError{Path: path, Message: fmt.Sprintf("%s is outside the config directory", file)})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Views)) {
+		view := cfg.Views[name]
+		viewPath := joinConfigPath("views", name)
+		templates := [][2]string{{"review", view.Templates.Review}, {"questions", view.Templates.Questions}, {"comparison", view.Templates.Comparison}}
+		for _, t := range templates {
+			if t[1] != "" {
+				check(joinConfigPath(viewPath, "templates", t[0]), t[1])
+			}
+		}
+		if isJobDescriptionFile(dir, view.JobDescription) {
+			check(joinConfigPath(viewPath, "job_description"), view.JobDescription)
+		}
+		if view.JobDescriptionFile != "" {
+			check(joinConfigPath(viewPath, "job_description_file"), view.JobDescriptionFile)
+		}
+		for i, include := range view.Include {
+			if isGroupFile(include) {
+				check(fmt.Sprintf("%s[%d]", joinConfigPath(viewPath, "include"), i), include)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigErrors(t *testing.T) {
+	const item = `{"question": "Does the candidate know Go?"}`
+	cases := []struct {
+		name      string
+		config    string
+		wantPaths []string
+	}{
+		{"valid", `{"views": {"dev": {"score_checklist": {"go": ` + item + `}}}}`, nil},
+		{"unknown field", `{"views": {"dev": {"score_checklist": {"go": {"questoin": "Go?"}}}}}`, []string{"views.dev.score_checklist.go.questoin"}},
+		{"wrong type", `{"views": {"dev": {"score_checklist": {"go": {"question": "Go?", "weight": "2"}}}}}`, []string{"views.dev.score_checklist.go.weight"}},
+		{"null", `{"views": {"dev": {"pretty_name": null, "score_checklist": {"go": ` + item + `}}}}`, []string{"views.dev.pretty_name"}},
+		{"duplicate key", `{"views": {"dev": {"score_checklist": {"go": ` + item + `, "go": ` + item + `}}}}`, []string{"views.dev.score_checklist.go"}},
+		{"every shape problem", `{"view": {}, "views": {"dev": {"score_checklist": []}}}`, []string{"view", "views.dev.score_checklist"}},
+		{"key needing quotes", `{"views": {"my view": {"score_checklist": {"go": {"question": 1}}}}}`, []string{`views["my view"].score_checklist.go.question`}},
+		{"invalid JSON", `{"views": {"dev": `, []string{"views.dev"}},
+		{"trailing data", `{"views": {}} {}`, []string{""}},
+		{"no views", `{"views": {}}`, []string{"views"}},
+		{"empty question", `{"views": {"dev": {"score_checklist": {"go": {"question": " "}}}}}`, []string{"views.dev.score_checklist.go.question"}},
+		{"unsafe view name", `{"views": {"my view": {"score_checklist": {"go": ` + item + `}}}}`, []string{`views["my view"]`}},
+		{"reserved key", `{"views": {"dev": {"score_checklist": {"final_score": ` + item + `}}}}`, []string{"views.dev.score_checklist.final_score"}},
+		{"key in checklist and questions", `{"views": {"dev": {"score_checklist": {"go": ` + item + `}, "specific_questions": {"go": ` + item + `}}}}`, []string{"views.dev.specific_questions.go"}},
+		{"two job descriptions", `{"views": {"dev": {"job_description": "Build APIs.", "job_description_file": "jd.txt", "score_checklist": {"go": ` + item + `}}}}`, []string{"views.dev.job_description_file"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(dir+"/config.json", []byte(c.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(dir + "/config.json")
+			if c.wantPaths == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("no error, want errors at %q", c.wantPaths)
+			}
+			paths := make([]string, 0)
+			for _, e := range ConfigErrors(err) {
+				paths = append(paths, e.Path)
+			}
+			if !slices.Equal(paths, c.wantPaths) {
+				t.Errorf("error paths = %q, want %q (%s)", paths, c.wantPaths, strings.ReplaceAll(err.Error(), "\n", "; "))
+			}
+		})
+	}
+}
+
+func TestParseConfigJSON(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/basics.yaml", []byte("score_checklist:\n  degree: {question: \"Does the candidate have a degree?\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/backend.txt", []byte("Build our payments API."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ParseConfigJSON([]byte(`{"views": {"dev": {"include": ["basics.yaml"], "job_description": "backend.txt"}}}`), dir)
+	if err != nil {
+		t.Fatalf("ParseConfigJSON() error = %v", err)
+	}
+	view := cfg.Views["dev"]
+	if _, ok := view.ScoreChecklist["degree"]; !ok {
+		t.Errorf("checklist = %v, want the item included from the group file in dir", view.ScoreChecklist)
+	}
+	if got := view.Prompts().JobDescription; got != "Build our payments API." {
+		t.Errorf("job description = %q, want the text of the file in dir", got)
+	}
+
+	// Files are only found relative to dir, not the working directory.
+	_, err = ParseConfigJSON([]byte(`{"views": {"dev": {"include": ["basics.yaml"]}}}`), t.TempDir())
+	if errs := ConfigErrors(err); err == nil || errs[0].Path != "views.dev.include[0]" {
+		t.Errorf("ParseConfigJSON() in another dir error = %v, want an error at views.dev.include[0]", err)
+	}
+}
+
+func TestValidateChecklistScoring(t *testing.T) {
+	cases := []struct {
+		name      string
+		item      string
+		wantPaths []string
+	}{
+		{"linear", `"type": "number", "scoring": {"min": 0, "max": 10}`, nil},
+		{"threshold", `"type": "number", "scoring": {"function": "threshold", "threshold": 5}`, nil},
+		{"threshold of zero", `"type": "number", "scoring": {"function": "threshold", "threshold": 0}`, nil},
+		{"default rating scoring", `"type": "rating"`, nil},
+		{"threshold without threshold", `"type": "number", "scoring": {"function": "threshold"}`, []string{"views.dev.score_checklist.years.scoring.threshold"}},
+		{"linear with threshold", `"type": "rating", "scoring": {"min": 1, "max": 5, "threshold": 3}`, []string{"views.dev.score_checklist.years.scoring.threshold"}},
+		{"linear without range", `"type": "number", "scoring": {"function": "linear"}`, []string{"views.dev.score_checklist.years.scoring"}},
+		{"number without scoring", `"type": "number"`, []string{"views.dev.score_checklist.years.scoring"}},
+		{"unknown function", `"type": "number", "scoring": {"function": "log"}`, []string{"views.dev.score_checklist.years.scoring.function"}},
+		{"scoring on boolean", `"scoring": {"min": 0, "max": 1}`, []string{"views.dev.score_checklist.years.scoring"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := `{"views": {"dev": {"score_checklist": {"years": {"question": "How many years of experience?", ` + c.item + `}}}}}`
+			_, err := ParseConfigJSON([]byte(config), t.TempDir())
+			paths := make([]string, 0)
+			if err != nil {
+				for _, e := range ConfigErrors(err) {
+					paths = append(paths, e.Path)
+				}
+			}
+			if !slices.Equal(paths, c.wantPaths) {
+				t.Errorf("error paths = %q, want %q (%v)", paths, c.wantPaths, err)
+			}
+		})
+	}
+}
+
+func TestValidateProfileReportColumns(t *testing.T) {
+	cases := []struct {
+		name      string
+		profile   string
+		wantPaths []string
+	}{
+		{"no columns", `{}`, nil},
+		{"known columns", `{"report_columns": ["email", "years_experience", "current_employer"]}`, nil},
+		{"unknown column", `{"report_columns": ["email", "salary"]}`, []string{"profile.report_columns[1]"}},
+		{"prefixed column", `{"report_columns": ["profile_email"]}`, []string{"profile.report_columns[0]"}},
+		{"duplicate column", `{"report_columns": ["email", "skills", "email"]}`, []string{"profile.report_columns[2]"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config := `{"profile": ` + c.profile + `, "views": {"dev": {"score_checklist": {"degree": {"question": "Does the candidate have a degree?"}}}}}`
+			_, err := ParseConfigJSON([]byte(config), t.TempDir())
+			paths := make([]string, 0)
+			if err != nil {
+				for _, e := range ConfigErrors(err) {
+					paths = append(paths, e.Path)
+				}
+			}
+			if !slices.Equal(paths, c.wantPaths) {
+				t.Errorf("error paths = %q, want %q (%v)", paths, c.wantPaths, err)
+			}
+		})
+	}
+}
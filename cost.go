@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/JoshPattman/jpf"
+)
+
+// ErrBudgetExceeded is returned by models built with a budget once the budget has been used up.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// isBudgetExceeded returns true if err, or every failure in it, is ErrBudgetExceeded.
+func isBudgetExceeded(err error) bool {
+	if list, ok := err.(failureList); ok {
+		for _, e := range list {
+			if !isBudgetExceeded(e) {
+				return false
+			}
+		}
+		return len(list) > 0
+	}
+	return errors.Is(err, ErrBudgetExceeded)
+}
+
+// ModelPrice is the price of a model in US dollars per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Cost returns the cost in US dollars of the given usage.
+func (p ModelPrice) Cost(usage jpf.Usage) float64 {
+	return float64(usage.InputTokens)*p.InputPerMillion/1e6 + float64(usage.OutputTokens)*p.OutputPerMillion/1e6
+}
+
+// knownModelPrices are the list prices for common OpenAI models at the time of writing.
+var knownModelPrices = map[string]ModelPrice{
+	"gpt-4.1":      {InputPerMillion: 2.00, OutputPerMillion: 8.00},
+	"gpt-4.1-mini": {InputPerMillion: 0.40, OutputPerMillion: 1.60},
+	"gpt-4.1-nano": {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+	"gpt-4o":       {InputPerMillion: 2.50, OutputPerMillion: 10.00},
+	"gpt-4o-mini":  {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+	"gpt-5":        {InputPerMillion: 1.25, OutputPerMillion: 10.00},
+	"gpt-5-mini":   {InputPerMillion: 0.25, OutputPerMillion: 2.00},
+	"gpt-5-nano":   {InputPerMillion: 0.05, OutputPerMillion: 0.40},
+	"o4-mini":      {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+}
+
+// PriceForModel looks up the price of the named model, returning false if it is not known.
+func PriceForModel(modelName string) (ModelPrice, bool) {
+	p, ok := knownModelPrices[modelName]
+	return p, ok
+}
+
+// Budget limits the tokens and money a run may spend, where zero means no limit.
+type Budget struct {
+	MaxTokens int
+	MaxCost   float64
+	Price     ModelPrice
+}
+
+// IsLimited returns true if the budget enforces any limit.
+func (b Budget) IsLimited() bool {
+	return b.MaxTokens > 0 || b.MaxCost > 0
+}
+
+// Check returns ErrBudgetExceeded if the given usage has reached either limit of the budget.
+func (b Budget) Check(usage jpf.Usage) error {
+	if b.MaxTokens > 0 {
+		tokens := usage.InputTokens + usage.OutputTokens
+		if tokens >= b.MaxTokens {
+			return errors.Join(fmt.Errorf("used %d of %d tokens", tokens, b.MaxTokens), ErrBudgetExceeded)
+		}
+	}
+	if b.MaxCost > 0 {
+		cost := b.Price.Cost(usage)
+		if cost >= b.MaxCost {
+			return errors.Join(fmt.Errorf("spent $%.4f of $%.4f", cost, b.MaxCost), ErrBudgetExceeded)
+		}
+	}
+	return nil
+}
+
+// reservedOutputTokens is a rough allowance for how many tokens the model writes in a single call.
+const reservedOutputTokens = 500
+
+// budgetTracker tracks the usage spent and reserved against a budget.
+type budgetTracker struct {
+	budget   Budget
+	lock     sync.Mutex
+	spent    jpf.Usage
+	reserved jpf.Usage
+}
+
+func newBudgetTracker(budget Budget) *budgetTracker {
+	return &budgetTracker{budget: budget}
+}
+
+// reserve sets aside the estimated usage of a call if it fits in the budget.
+func (t *budgetTracker) reserve(estimate jpf.Usage) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	committed := t.spent.Add(t.reserved)
+	if err := t.budget.Check(committed); err != nil {
+		return err
+	}
+	if err := t.budget.Check(committed.Add(estimate)); err != nil {
+		return fmt.Errorf("not enough budget left for a call of about %d tokens: %w", estimate.InputTokens+estimate.OutputTokens, err)
+	}
+	t.reserved = t.reserved.Add(estimate)
+	return nil
+}
+
+// settle replaces the reservation of a finished call with its actual usage.
+func (t *budgetTracker) settle(estimate, actual jpf.Usage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.reserved.InputTokens -= estimate.InputTokens
+	t.reserved.OutputTokens -= estimate.OutputTokens
+	t.spent = t.spent.Add(actual)
+}
+
+// newBudgetModel wraps a model so that it refuses calls that may exceed the budget.
+func newBudgetModel(model jpf.Model, tracker *budgetTracker) jpf.Model {
+	return &budgetModel{
+		model:   model,
+		tracker: tracker,
+	}
+}
+
+type budgetModel struct {
+	model   jpf.Model
+	tracker *budgetTracker
+}
+
+func (m *budgetModel) Respond(ctx context.Context, msgs []jpf.Message) (jpf.ModelResponse, error) {
+	estimate := jpf.Usage{InputTokens: estimateMessagesTokens(msgs), OutputTokens: reservedOutputTokens}
+	if err := m.tracker.reserve(estimate); err != nil {
+		return jpf.ModelResponse{}, err
+	}
+	resp, err := m.model.Respond(ctx, msgs)
+	m.tracker.settle(estimate, resp.Usage)
+	return resp, err
+}
+
+// tokenPattern splits text roughly the way BPE tokenisers pre-split it.
+var tokenPattern = regexp.MustCompile(`\p{L}+|\p{N}{1,3}|[^\s\p{L}\p{N}]+|\s+`)
+
+// estimateTokens approximates the number of tokens in text, erring on the high side.
+func estimateTokens(text string) int {
+	total := 0
+	for _, piece := range tokenPattern.FindAllString(text, -1) {
+		if strings.TrimSpace(piece) == "" {
+			// Whitespace is mostly merged into the following word.
+			if len(piece) > 1 {
+				total++
+			}
+			continue
+		}
+		total += (len([]rune(piece)) + 3) / 4
+	}
+	return total
+}
+
+// estimateMessagesTokens approximates the number of input tokens of the messages.
+func estimateMessagesTokens(msgs []jpf.Message) int {
+	total := 0
+	for _, msg := range msgs {
+		total += 4 + estimateTokens(msg.Content)
+	}
+	return total
+}
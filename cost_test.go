@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/JoshPattman/jpf"
+)
+
+func TestIsBudgetExceeded(t *testing.T) {
+	budgetErr := Budget{MaxTokens: 10}.Check(jpf.Usage{InputTokens: 20})
+	// jpf adds context to a failed call by joining a message onto the error.
+	wrappedBudgetErr := errors.Join(errors.New("failed to get model response"), budgetErr)
+	apiErr := errors.Join(errors.New("failed to get model response"), errors.New("401 unauthorized"))
+
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"budget", budgetErr, true},
+		{"wrapped budget", wrappedBudgetErr, true},
+		{"other failure", apiErr, false},
+		{"all budget failures", joinFailures(wrappedBudgetErr, budgetErr), true},
+		{"nested budget failures", joinFailures(joinFailures(budgetErr, nil), wrappedBudgetErr), true},
+		{"budget and other failure", joinFailures(wrappedBudgetErr, apiErr), false},
+		{"nested other failure", joinFailures(budgetErr, joinFailures(budgetErr, apiErr)), false},
+		{"wrapped with fmt", fmt.Errorf("view failed: %w", budgetErr), true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isBudgetExceeded(c.err); got != c.want {
+				t.Errorf("isBudgetExceeded() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestJoinFailures(t *testing.T) {
+	if err := joinFailures(nil, nil); err != nil {
+		t.Errorf("joinFailures(nil, nil) = %v, want nil", err)
+	}
+	a, b := errors.New("a"), errors.New("b")
+	err := joinFailures(a, nil, b)
+	if !errors.Is(err, a) || !errors.Is(err, b) {
+		t.Errorf("joinFailures(a, nil, b) = %v, want it to wrap a and b", err)
+	}
+	if err.Error() != "a\nb" {
+		t.Errorf("joinFailures(a, nil, b).Error() = %q, want %q", err.Error(), "a\nb")
+	}
+}
+
+// fakeModel responds to every call with respond, which is given the content of the last message.
+type fakeModel struct {
+	respond func(prompt string) (string, error)
+	usage   jpf.Usage
+}
+
+func (m fakeModel) Respond(_ context.Context, msgs []jpf.Message) (jpf.ModelResponse, error) {
+	text, err := m.respond(msgs[len(msgs)-1].Content)
+	if err != nil {
+		return jpf.ModelResponse{Usage: m.usage}, err
+	}
+	return jpf.ModelResponse{PrimaryMessage: jpf.Message{Role: jpf.AssistantRole, Content: text}, Usage: m.usage}, nil
+}
+
+// fakeModelBuilder builds the same model for every call.
+type fakeModelBuilder struct {
+	model jpf.Model
+}
+
+func (b fakeModelBuilder) BuildCandidateReviewModel(*slog.Logger, map[string]any) jpf.Model {
+	return b.model
+}
+
+func (b fakeModelBuilder) UsageCounter() *jpf.UsageCounter {
+	return jpf.NewUsageCounter()
+}
+
+func TestBudgetModelReservesInFlightCalls(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	inner := fakeModel{respond: func(string) (string, error) {
+		started <- struct{}{}
+		<-release
+		return "{}", nil
+	}, usage: jpf.Usage{InputTokens: 50, OutputTokens: 50}}
+	model := newBudgetModel(inner, newBudgetTracker(Budget{MaxTokens: 1000}))
+	msgs := []jpf.Message{{Role: jpf.UserRole, Content: "hi"}}
+
+	firstErr := make(chan error)
+	go func() {
+		_, err := model.Respond(context.Background(), msgs)
+		firstErr <- err
+	}()
+	<-started
+	// The first call has reserved about half of the budget, so a second call at the same time might not fit.
+	if _, err := model.Respond(context.Background(), msgs); !isBudgetExceeded(err) {
+		t.Errorf("Respond() during another call error = %v, want ErrBudgetExceeded", err)
+	}
+	close(release)
+	if err := <-firstErr; err != nil {
+		t.Fatalf("Respond() error = %v", err)
+	}
+	// Once the first call has settled at its actual usage, there is room again.
+	go func() { <-started }()
+	if _, err := model.Respond(context.Background(), msgs); err != nil {
+		t.Errorf("Respond() after the first call settled error = %v, want nil", err)
+	}
+}
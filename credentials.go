@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// apiKeyEnvVars are the variables the API key is read from, in order of precedence.
+var apiKeyEnvVars = []string{"CVSCAN_API_KEY", "OPENAI_API_KEY"}
+
+// ResolveAPIKey finds the API key from the flag, secrets file, environment or .env file.
+func ResolveAPIKey(flagKey string, secretsFile string, dotEnvPath string) (string, string, error) {
+	if flagKey != "" {
+		return flagKey, "the -k flag", nil
+	}
+	if secretsFile != "" {
+		data, err := os.ReadFile(secretsFile)
+		if err != nil {
+			return "", "", errors.Join(errors.New("failed to read secrets file"), err)
+		}
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return "", "", fmt.Errorf("secrets file %s is empty", secretsFile)
+		}
+		return key, "secrets file " + secretsFile, nil
+	}
+	for _, name := range apiKeyEnvVars {
+		if key := strings.TrimSpace(os.Getenv(name)); key != "" {
+			return key, "environment variable " + name, nil
+		}
+	}
+	dotEnv, err := readDotEnv(dotEnvPath)
+	if err != nil {
+		return "", "", errors.Join(fmt.Errorf("failed to read %s", dotEnvPath), err)
+	}
+	for _, name := range apiKeyEnvVars {
+		if key := dotEnv[name]; key != "" {
+			return key, fmt.Sprintf("%s in %s", name, dotEnvPath), nil
+		}
+	}
+	return "", "", nil
+}
+
+// readDotEnv parses a .env file of KEY=VALUE lines, treating a missing file as empty.
+func readDotEnv(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d is not of the form KEY=VALUE", lineNum)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	secrets := writeFile("secret", " sk-secrets\n")
+	emptySecrets := writeFile("empty_secret", "\n")
+	dotEnv := writeFile(".env", "OPENAI_API_KEY=sk-dotenv-openai\nCVSCAN_API_KEY=sk-dotenv-cvscan\n")
+	openAIDotEnv := writeFile("openai.env", "OPENAI_API_KEY=sk-dotenv-openai\n")
+	invalidDotEnv := writeFile("invalid.env", "OPENAI_API_KEY\n")
+	missing := filepath.Join(dir, "missing")
+	cases := []struct {
+		name        string
+		flagKey     string
+		secretsFile string
+		env         map[string]string
+		dotEnvPath  string
+		wantKey     string
+		wantSource  string
+		wantErr     bool
+	}{
+		{"flag beats everything", "sk-flag", secrets, map[string]string{"CVSCAN_API_KEY": "sk-env"}, dotEnv, "sk-flag", "the -k flag", false},
+		{"secrets file beats env", "", secrets, map[string]string{"CVSCAN_API_KEY": "sk-env"}, dotEnv, "sk-secrets", "secrets file " + secrets, false},
+		{"env beats .env", "", "", map[string]string{"OPENAI_API_KEY": "sk-env-openai"}, dotEnv, "sk-env-openai", "environment variable OPENAI_API_KEY", false},
+		{"cvscan env beats openai env", "", "", map[string]string{"CVSCAN_API_KEY": "sk-env", "OPENAI_API_KEY": "sk-env-openai"}, dotEnv, "sk-env", "environment variable CVSCAN_API_KEY", false},
+		{"blank env is ignored", "", "", map[string]string{"CVSCAN_API_KEY": "  "}, openAIDotEnv, "sk-dotenv-openai", "OPENAI_API_KEY in " + openAIDotEnv, false},
+		{"cvscan .env entry beats openai entry", "", "", nil, dotEnv, "sk-dotenv-cvscan", "CVSCAN_API_KEY in " + dotEnv, false},
+		{"nothing set", "", "", nil, missing, "", "", false},
+		{"missing secrets file", "", missing, nil, dotEnv, "", "", true},
+		{"empty secrets file", "", emptySecrets, nil, dotEnv, "", "", true},
+		{"invalid .env", "", "", nil, invalidDotEnv, "", "", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, name := range apiKeyEnvVars {
+				t.Setenv(name, c.env[name])
+			}
+			key, source, err := ResolveAPIKey(c.flagKey, c.secretsFile, c.dotEnvPath)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("ResolveAPIKey() error = %v, want error %v", err, c.wantErr)
+			}
+			if key != c.wantKey || source != c.wantSource {
+				t.Errorf("ResolveAPIKey() = %q from %q, want %q from %q", key, source, c.wantKey, c.wantSource)
+			}
+		})
+	}
+}
+
+func TestReadDotEnv(t *testing.T) {
+	cases := []struct {
+		name    string
+		text    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"plain", "KEY=value", map[string]string{"KEY": "value"}, false},
+		{"double quotes", `KEY="value with spaces"`, map[string]string{"KEY": "value with spaces"}, false},
+		{"single quotes", "KEY='value'", map[string]string{"KEY": "value"}, false},
+		{"mismatched quotes", `KEY="value'`, map[string]string{"KEY": `"value'`}, false},
+		{"comments and blank lines", "# a comment\n\n  # indented comment\nKEY=value\n", map[string]string{"KEY": "value"}, false},
+		{"export prefix", "export KEY=value", map[string]string{"KEY": "value"}, false},
+		{"spaces around", "  KEY = value  ", map[string]string{"KEY": "value"}, false},
+		{"equals in value", "KEY=a=b", map[string]string{"KEY": "a=b"}, false},
+		{"later entries win", "KEY=first\nKEY=second", map[string]string{"KEY": "second"}, false},
+		{"empty value", "KEY=", map[string]string{"KEY": ""}, false},
+		{"no equals", "KEY", nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(c.text), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readDotEnv(path)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("readDotEnv() error = %v, want error %v", err, c.wantErr)
+			}
+			if !c.wantErr && !maps.Equal(got, c.want) {
+				t.Errorf("readDotEnv() = %v, want %v", got, c.want)
+			}
+		})
+	}
+	got, err := readDotEnv(filepath.Join(t.TempDir(), ".env"))
+	if err != nil || len(got) != 0 {
+		t.Errorf("readDotEnv() of a missing file = %v, %v, want no values and no error", got, err)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DuplicateGroup is a set of CV files believed to belong to the same candidate.
+type DuplicateGroup struct {
+	Primary    string
+	Duplicates []string
+	// Reasons explains why each duplicate was grouped, keyed by the duplicate's path.
+	Reasons map[string]string
+}
+
+// FindDuplicates groups the texts (keyed by file path) by candidate.
+func FindDuplicates(texts map[string]string, minSimilarity float64) []DuplicateGroup {
+	paths := slices.Sorted(maps.Keys(texts))
+	fingerprints := make([]dedupFingerprint, len(paths))
+	for i, p := range paths {
+		fingerprints[i] = newDedupFingerprint(texts[p])
+	}
+
+	parent := make([]int, len(paths))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	reasons := make(map[string]string)
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			reason := fingerprints[i].duplicateReason(fingerprints[j], minSimilarity)
+			if reason == "" {
+				continue
+			}
+			if _, ok := reasons[paths[j]]; !ok {
+				reasons[paths[j]] = reason + " as " + paths[i]
+			}
+			parent[find(j)] = find(i)
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range paths {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	groups := make([]DuplicateGroup, 0, len(members))
+	for _, idxs := range members {
+		// The longest text is reviewed, as it is likely the most complete version of the CV.
+		primary := slices.MaxFunc(idxs, func(a, b int) int {
+			if len(texts[paths[a]]) != len(texts[paths[b]]) {
+				return len(texts[paths[a]]) - len(texts[paths[b]])
+			}
+			return strings.Compare(paths[b], paths[a])
+		})
+		group := DuplicateGroup{Primary: paths[primary], Duplicates: []string{}, Reasons: map[string]string{}}
+		for _, i := range idxs {
+			if i == primary {
+				continue
+			}
+			group.Duplicates = append(group.Duplicates, paths[i])
+			group.Reasons[paths[i]] = reasons[paths[i]]
+			if group.Reasons[paths[i]] == "" {
+				group.Reasons[paths[i]] = "same candidate as " + paths[primary]
+			}
+		}
+		groups = append(groups, group)
+	}
+	slices.SortFunc(groups, func(a, b DuplicateGroup) int { return strings.Compare(a.Primary, b.Primary) })
+	return groups
+}
+
+// singleFileGroups puts every file in its own group, for when duplicates should not be detected.
+func singleFileGroups(texts map[string]string) []DuplicateGroup {
+	groups := make([]DuplicateGroup, 0, len(texts))
+	for _, p := range slices.Sorted(maps.Keys(texts)) {
+		groups = append(groups, DuplicateGroup{Primary: p, Duplicates: []string{}, Reasons: map[string]string{}})
+	}
+	return groups
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	wordPattern  = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// dedupFingerprint is the information about a text that is used to find duplicates.
+type dedupFingerprint struct {
+	hash     string
+	numWords int
+	// firstEmail is the first email address in the text.
+	firstEmail string
+	shingles   map[string]bool
+}
+
+// shingleSize is the number of consecutive words in each shingle used for similarity.
+const shingleSize = 3
+
+// minDedupWords is the fewest words a text must have to be compared with others.
+const minDedupWords = 30
+
+// emailMinSimilarity is how similar two texts with the same email must be.
+const emailMinSimilarity = 0.3
+
+func newDedupFingerprint(text string) dedupFingerprint {
+	words := wordPattern.FindAllString(strings.ToLower(text), -1)
+	hash := sha256.Sum256([]byte(strings.Join(words, " ")))
+	fp := dedupFingerprint{
+		hash:       hex.EncodeToString(hash[:]),
+		numWords:   len(words),
+		firstEmail: strings.ToLower(emailPattern.FindString(text)),
+		shingles:   make(map[string]bool),
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		fp.shingles[strings.Join(words[i:i+shingleSize], " ")] = true
+	}
+	return fp
+}
+
+// HasTooLittleText returns true if the text has too few words to be a CV.
+func HasTooLittleText(text string) bool {
+	return len(wordPattern.FindAllString(text, minDedupWords)) < minDedupWords
+}
+
+// duplicateReason returns why the fingerprints are duplicates, or an empty string.
+func (fp dedupFingerprint) duplicateReason(other dedupFingerprint, minSimilarity float64) string {
+	if fp.numWords < minDedupWords || other.numWords < minDedupWords {
+		return ""
+	}
+	if fp.hash == other.hash {
+		return "same text"
+	}
+	similarity := jaccardSimilarity(fp.shingles, other.shingles)
+	if fp.firstEmail != "" && fp.firstEmail == other.firstEmail && similarity >= emailMinSimilarity {
+		return "same email address " + fp.firstEmail + " and similar text"
+	}
+	if minSimilarity <= 1 && similarity >= minSimilarity {
+		return "very similar text"
+	}
+	return ""
+}
+
+// jaccardSimilarity returns the Jaccard similarity of a and b.
+func jaccardSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for k := range a {
+		if b[k] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
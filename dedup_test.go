@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testCV returns a CV with enough text to be compared, made of the header followed by words unique to seed.
+func testCV(header string, seed string, numWords int) string {
+	words := make([]string, numWords)
+	for i := range words {
+		words[i] = fmt.Sprintf("%s%d", seed, i)
+	}
+	return header + "\n" + strings.Join(words, " ")
+}
+
+func TestDuplicateReason(t *testing.T) {
+	alice := testCV("Alice Smith alice@example.com", "alice", 60)
+	cases := []struct {
+		name       string
+		a, b       string
+		similarity float64
+		want       string
+	}{
+		{"same text", alice, alice, 0.9, "same text"},
+		{"same text different case and spacing", alice, strings.ToUpper(strings.ReplaceAll(alice, " ", "  ")), 0.9, "same text"},
+		{"both empty", "", "", 0.9, ""},
+		{"both almost empty", "Page 1", "Page 1", 0.9, ""},
+		{"one empty", alice, "", 0.9, ""},
+		{"different people", alice, testCV("Bob Jones bob@example.com", "bob", 60), 0.9, ""},
+		{"shared agency email", testCV("Sent by jobs@agency.com", "alice", 60), testCV("Sent by jobs@agency.com", "bob", 60), 0.9, ""},
+		{"shared email on similar text", alice, alice + " " + testCV("", "extra", 20), 0.99, "same email address alice@example.com and similar text"},
+		{"shared email later in the text", testCV("Alice alice@example.com", "alice", 60) + " referee ref@example.com", testCV("Bob bob@example.com", "bob", 60) + " referee ref@example.com", 0.9, ""},
+		{"very similar text", testCV("Alice Smith", "alice", 60), testCV("Alice Smith", "alice", 60) + " one more line", 0.9, "very similar text"},
+		{"fuzzy matching disabled", testCV("", "alice", 60), testCV("", "alice", 60) + " one more line", 1.1, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := newDedupFingerprint(c.a).duplicateReason(newDedupFingerprint(c.b), c.similarity)
+			if got != c.want {
+				t.Errorf("duplicateReason() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	alice := testCV("Alice Smith alice@example.com", "alice", 60)
+	texts := map[string]string{
+		"alice.pdf":      alice,
+		"alice_copy.pdf": alice,
+		"bob.pdf":        testCV("Sent by jobs@agency.com", "bob", 60),
+		"carol.pdf":      testCV("Sent by jobs@agency.com", "carol", 60),
+		"scan1.pdf":      "",
+		"scan2.pdf":      "",
+	}
+	groups := FindDuplicates(texts, 0.9)
+	got := make(map[string][]string)
+	for _, g := range groups {
+		got[g.Primary] = g.Duplicates
+	}
+	want := map[string][]string{
+		"alice.pdf": {"alice_copy.pdf"},
+		"bob.pdf":   {},
+		"carol.pdf": {},
+		"scan1.pdf": {},
+		"scan2.pdf": {},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("FindDuplicates() groups = %v, want %v", got, want)
+	}
+}
+
+func TestHasTooLittleText(t *testing.T) {
+	if !HasTooLittleText("") || !HasTooLittleText("Page 1 of 2") {
+		t.Error("HasTooLittleText() = false for an almost empty text")
+	}
+	if HasTooLittleText(testCV("Alice", "alice", 60)) {
+		t.Error("HasTooLittleText() = true for a full CV")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// evidenceReplacer replaces typographic punctuation with plain characters.
+var evidenceReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'",
+	"“", "\"", "”", "\"",
+	"–", "-", "—", "-",
+	" ", " ",
+)
+
+// normaliseEvidenceText ignores case, punctuation style and whitespace in text.
+func normaliseEvidenceText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(evidenceReplacer.Replace(text))), " ")
+}
+
+// evidenceProblems returns a problem for every quote that is not in the resume.
+func evidenceProblems(resume string, answers map[string]responseItem[checklistAnswer]) []string {
+	normalisedResume := normaliseEvidenceText(resume)
+	problems := make([]string, 0)
+	for _, key := range slices.Sorted(maps.Keys(answers)) {
+		for _, quote := range answers[key].Evidence {
+			normalised := normaliseEvidenceText(quote)
+			if normalised == "" {
+				problems = append(problems, fmt.Sprintf("%q has an empty quote in \"evidence\", remove it", key))
+			} else if !strings.Contains(normalisedResume, normalised) {
+				problems = append(problems, fmt.Sprintf("%q has a quote in \"evidence\" that is not in the resume, every quote must be copied exactly from the resume: %q", key, quote))
+			}
+		}
+	}
+	return problems
+}
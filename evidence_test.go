@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormaliseEvidenceText(t *testing.T) {
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"Led the  Payments\nteam", "led the payments team"},
+		{"“Shipped” the candidate’s API", "\"shipped\" the candidate's api"},
+		{"2019 – 2023", "2019 - 2023"},
+		{"  \n\t", ""},
+	}
+	for _, c := range cases {
+		if got := normaliseEvidenceText(c.text); got != c.want {
+			t.Errorf("normaliseEvidenceText(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestEvidenceProblems(t *testing.T) {
+	resume := "Experience\nSenior engineer at Acme, 2019–2023.\nLed the “Payments” team and\nmigrated services to Go."
+	cases := []struct {
+		name     string
+		evidence []string
+		want     string
+	}{
+		{"no evidence", nil, ""},
+		{"exact quote", []string{"Senior engineer at Acme"}, ""},
+		{"quote across a line break", []string{"Led the \"Payments\" team and migrated services"}, ""},
+		{"different case and dash", []string{"senior engineer at acme, 2019-2023"}, ""},
+		{"made up quote", []string{"Senior engineer at Google"}, "not in the resume"},
+		{"empty quote", []string{" "}, "empty quote"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			answers := map[string]responseItem[checklistAnswer]{"go": {Reasoning: "r", Evidence: c.evidence}}
+			problems := evidenceProblems(resume, answers)
+			if c.want == "" && len(problems) > 0 {
+				t.Errorf("evidenceProblems() = %v, want none", problems)
+			}
+			if c.want != "" && (len(problems) != 1 || !strings.Contains(problems[0], c.want)) {
+				t.Errorf("evidenceProblems() = %v, want one containing %q", problems, c.want)
+			}
+		})
+	}
+}
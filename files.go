@@ -3,12 +3,16 @@ package main
 import (
 	"bytes"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ledongthuc/pdf"
 )
@@ -32,11 +36,20 @@ func GetTextFromPDFFile(file string) (string, error) {
 
 // CandidateReport represents the report for a single candidate.
 type CandidateReport struct {
-	FileName   string
-	FileLoc    string
-	Checklist  map[string]CandidateQuestionResult
-	FinalScore float64
-	Questions  map[string]CandidateTextQuestionResult
+	// CandidateID is the candidate's stable ID, which is the same across runs and in the logs.
+	CandidateID string
+	FileName    string
+	FileLoc     string
+	Duplicates  []string
+	Checklist   map[string]CandidateQuestionResult
+	FinalScore  float64
+	Questions   map[string]CandidateTextQuestionResult
+	// TournamentRank is the candidate's tournament position, or 0 if they did not take part.
+	TournamentRank int
+	// TournamentScore is the candidate's Bradley–Terry strength in the tournament.
+	TournamentScore float64
+	// Profile is the candidate's extracted profile, or nil if profile extraction is off or failed.
+	Profile *CandidateProfile
 }
 
 // ReportMode defines the mode of report generation.
@@ -57,26 +70,45 @@ func WriteTextFile(filename string, content string) error {
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 	_, err = f.WriteString(content)
 	if err != nil {
+		f.Close()
 		return err
 	}
-	return nil
+	// Closing can report a failed write, such as a full disk.
+	return f.Close()
+}
+
+// WriteTextFileIfChanged writes the content to the file unless it is unchanged.
+func WriteTextFileIfChanged(filename string, content string) error {
+	if existing, err := os.ReadFile(filename); err == nil && string(existing) == content {
+		return nil
+	}
+	return WriteTextFile(filename, content)
+}
+
+// CSVReportOptions chooses the optional columns of CSV reports.
+type CSVReportOptions struct {
+	// Duplicates adds a column listing the duplicate files of each candidate.
+	Duplicates bool
+	// ProfileFields are the profile fields added as columns.
+	ProfileFields []string
+	// Now is the time that profile fields such as years of experience are worked out at.
+	Now time.Time
 }
 
 // WriteCandidateReportsAsCSVFile writes the candidate reports to a CSV file in the specified mode.
-func WriteCandidateReportsAsCSVFile(filename string, reports []CandidateReport, mode ReportMode) error {
+func WriteCandidateReportsAsCSVFile(filename string, reports []CandidateReport, mode ReportMode, opts CSVReportOptions) error {
 	f, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	return WriteCandidateReportsAsCSV(f, reports, mode)
+	return WriteCandidateReportsAsCSV(f, reports, mode, opts)
 }
 
 // WriteCandidateReportsAsCSV writes the candidate reports to the provided writer in CSV format.
-func WriteCandidateReportsAsCSV(w io.Writer, reports []CandidateReport, mode ReportMode) error {
+func WriteCandidateReportsAsCSV(w io.Writer, reports []CandidateReport, mode ReportMode, opts CSVReportOptions) error {
 	cw := csv.NewWriter(w)
 
 	// Collect all checklist keys
@@ -110,9 +142,20 @@ func WriteCandidateReportsAsCSV(w io.Writer, reports []CandidateReport, mode Rep
 	sort.Strings(questionKeys)
 
 	// Build header
-	header := []string{"file_name", "file_loc"}
+	header := []string{"candidate_id", "file_name", "file_loc"}
+	if opts.Duplicates {
+		header = append(header, "duplicates")
+	}
+	for _, c := range opts.ProfileFields {
+		header = append(header, profileReportColumnPrefix+c)
+	}
 	header = append(header, keys...)
 	header = append(header, "final_score")
+	// Tournament columns are only added for views that ran a tournament.
+	hasTournament := slices.ContainsFunc(reports, func(r CandidateReport) bool { return r.TournamentRank > 0 })
+	if hasTournament {
+		header = append(header, "tournament_rank", "tournament_score")
+	}
 	header = append(header, questionKeys...)
 
 	if err := cw.Write(header); err != nil {
@@ -122,9 +165,24 @@ func WriteCandidateReportsAsCSV(w io.Writer, reports []CandidateReport, mode Rep
 	for _, r := range reports {
 		row := make([]string, 0, len(header))
 
-		row = append(row, r.FileName, r.FileLoc)
+		row = append(row, r.CandidateID, r.FileName, r.FileLoc)
+		if opts.Duplicates {
+			row = append(row, strings.Join(r.Duplicates, ";"))
+		}
+		for _, c := range opts.ProfileFields {
+			if r.Profile != nil {
+				row = append(row, profileColumns[c](*r.Profile, opts.Now))
+			} else {
+				row = append(row, "")
+			}
+		}
 
 		for _, k := range keys {
+			// Number and rating items report their aggregated answer instead of true/false or a probability.
+			if r.Checklist[k].IsNumeric() && mode != Inconsistency {
+				row = append(row, strconv.FormatFloat(math.Round(r.Checklist[k].Value()*1000)/1000, 'f', -1, 64))
+				continue
+			}
 			switch mode {
 			case Boolean:
 				if r.Checklist[k].IsTrue() {
@@ -140,13 +198,24 @@ func WriteCandidateReportsAsCSV(w io.Writer, reports []CandidateReport, mode Rep
 		}
 
 		row = append(row, strconv.FormatFloat(r.FinalScore, 'f', -1, 64))
+		if hasTournament && r.TournamentRank > 0 {
+			row = append(row, strconv.Itoa(r.TournamentRank), strconv.FormatFloat(r.TournamentScore, 'f', -1, 64))
+		} else if hasTournament {
+			row = append(row, "", "")
+		}
 
-		// Append question answers
+		// Append question answers, or for multiple-choice questions, how the votes were split in the probabilities and inconsistency reports.
 		for _, qk := range questionKeys {
-			if ans, ok := r.Questions[qk]; ok {
-				row = append(row, strings.ReplaceAll(ans.Answer, ",", ";"))
-			} else {
+			ans, ok := r.Questions[qk]
+			switch {
+			case !ok:
 				row = append(row, "")
+			case ans.Votes != nil && mode == Probability:
+				row = append(row, strings.ReplaceAll(ans.VoteDistribution(), ",", ";"))
+			case ans.Votes != nil && mode == Inconsistency:
+				row = append(row, fmt.Sprintf("%.3f", ans.Inconsistency()))
+			default:
+				row = append(row, strings.ReplaceAll(ans.Answer, ",", ";"))
 			}
 		}
 
@@ -158,3 +227,46 @@ func WriteCandidateReportsAsCSV(w io.Writer, reports []CandidateReport, mode Rep
 	cw.Flush()
 	return cw.Error()
 }
+
+// profileFileEntry is the profile of one candidate in the profiles file.
+type profileFileEntry struct {
+	CandidateID string            `json:"candidate_id"`
+	FileName    string            `json:"file_name"`
+	Profile     *CandidateProfile `json:"profile"`
+}
+
+// WriteProfilesFile writes the extracted profile of every candidate to a JSON file.
+func WriteProfilesFile(filename string, candidates []Candidate) error {
+	entries := make([]profileFileEntry, len(candidates))
+	for i, c := range candidates {
+		entries[i] = profileFileEntry{CandidateID: c.ID, FileName: c.Name, Profile: c.Profile}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteTextFileIfChanged(filename, string(data))
+}
+
+// WriteAnonymisationReportFile writes a CSV file of what anonymisation removed.
+func WriteAnonymisationReportFile(filename string, candidates []Candidate) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cw := csv.NewWriter(f)
+	if err := cw.Write([]string{"candidate_id", "file_name", "file_loc", "category", "removed", "count"}); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, c := range candidates {
+		for _, item := range c.Anonymised {
+			row := []string{c.ID, c.Name, c.Path, item.Category, item.Removed, strconv.Itoa(item.Count)}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("write row: %w", err)
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
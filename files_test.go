@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"testing"
+	"time"
+)
+
+// readReportCSV writes reports as a CSV and reads it back as rows.
+func readReportCSV(t *testing.T, reports []CandidateReport, opts CSVReportOptions) [][]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteCandidateReportsAsCSV(&buf, reports, Boolean, opts); err != nil {
+		t.Fatalf("WriteCandidateReportsAsCSV() error = %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV: %v", err)
+	}
+	return rows
+}
+
+func TestWriteCandidateReportsAsCSVDuplicates(t *testing.T) {
+	reports := []CandidateReport{
+		{CandidateID: "c1", FileName: "alice.pdf", FileLoc: "pdf/alice.pdf", Duplicates: []string{"pdf/alice_2.pdf", "pdf/alice_3.pdf"}, FinalScore: 1},
+		{CandidateID: "c2", FileName: "bob.pdf", FileLoc: "pdf/bob.pdf", Duplicates: []string{}, FinalScore: 0.5},
+	}
+	cases := []struct {
+		name     string
+		opts     CSVReportOptions
+		wantRows [][]string
+	}{
+		{"without dedup", CSVReportOptions{}, [][]string{
+			{"candidate_id", "file_name", "file_loc", "final_score"},
+			{"c1", "alice.pdf", "pdf/alice.pdf", "1"},
+			{"c2", "bob.pdf", "pdf/bob.pdf", "0.5"},
+		}},
+		{"with dedup", CSVReportOptions{Duplicates: true}, [][]string{
+			{"candidate_id", "file_name", "file_loc", "duplicates", "final_score"},
+			{"c1", "alice.pdf", "pdf/alice.pdf", "pdf/alice_2.pdf;pdf/alice_3.pdf", "1"},
+			{"c2", "bob.pdf", "pdf/bob.pdf", "", "0.5"},
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rows := readReportCSV(t, reports, c.opts)
+			if !slices.EqualFunc(rows, c.wantRows, slices.Equal) {
+				t.Errorf("CSV rows = %q, want %q", rows, c.wantRows)
+			}
+		})
+	}
+}
+
+func TestWriteCandidateReportsAsCSVProfile(t *testing.T) {
+	profile := CandidateProfile{
+		Name:      "Alice Smith",
+		Email:     "alice@example.com",
+		Roles:     []ProfileRole{{Title: "Engineer", Employer: "Acme", StartDate: "2024-07", EndDate: "present"}},
+		Education: []ProfileEducation{{Qualification: "BSc", Field: "Physics", Institution: "MIT", EndDate: "2019"}, {Institution: "School"}},
+		Skills:    []string{"Go", "SQL"},
+	}
+	reports := []CandidateReport{
+		{CandidateID: "c1", FileName: "alice.pdf", FileLoc: "pdf/alice.pdf", Profile: &profile, FinalScore: 1},
+		{CandidateID: "c2", FileName: "bob.pdf", FileLoc: "pdf/bob.pdf", FinalScore: 0.5},
+	}
+	opts := CSVReportOptions{
+		ProfileFields: []string{"email", "current_employer", "years_experience", "education", "skills"},
+		Now:           time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC),
+	}
+	wantRows := [][]string{
+		{"candidate_id", "file_name", "file_loc", "profile_email", "profile_current_employer", "profile_years_experience", "profile_education", "profile_skills", "final_score"},
+		{"c1", "alice.pdf", "pdf/alice.pdf", "alice@example.com", "Acme", "2", "BSc Physics MIT 2019;School", "Go;SQL", "1"},
+		{"c2", "bob.pdf", "pdf/bob.pdf", "", "", "", "", "", "0.5"},
+	}
+	if rows := readReportCSV(t, reports, opts); !slices.EqualFunc(rows, wantRows, slices.Equal) {
+		t.Errorf("CSV rows = %q, want %q", rows, wantRows)
+	}
+}
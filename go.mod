@@ -3,15 +3,17 @@ module github.com/JoshPattman/cvscan
 go 1.25.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/JoshPattman/jpf v0.9.0-beta.3
 	github.com/MatusOllah/slogcolor v1.7.0
 	github.com/fatih/color v1.16.0
 	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+	github.com/mattn/go-isatty v0.0.20
+	golang.org/x/sys v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.14.0 // indirect
 	golang.org/x/time v0.14.0 // indirect
 )
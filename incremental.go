@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ScanState records the results of a view, so later runs only review new CVs.
+type ScanState struct {
+	// ViewHash identifies everything that affects the model's answers for the view.
+	ViewHash string `json:"view_hash"`
+	// Candidates are the stored results, keyed by the hash of the reviewed text.
+	Candidates map[string]ScanStateCandidate `json:"candidates"`
+	// used is the keys of the candidates that were looked up or stored in this run.
+	used map[string]bool
+}
+
+// ScanStateCandidate is the stored result of reviewing one candidate.
+type ScanStateCandidate struct {
+	// Checklist is the probability or aggregated answer of each checklist item.
+	Checklist          map[string]float64 `json:"checklist"`
+	ChecklistReasoning map[string]string  `json:"checklist_reasoning,omitempty"`
+	// ChecklistVariance is the variance of the answers of each numeric item.
+	ChecklistVariance map[string]float64 `json:"checklist_variance,omitempty"`
+	// ChecklistEvidence is the quotes supporting each checklist answer.
+	ChecklistEvidence map[string][]string                    `json:"checklist_evidence,omitempty"`
+	Questions         map[string]CandidateTextQuestionResult `json:"questions"`
+}
+
+// NewScanState creates an empty scan state for a view.
+func NewScanState(viewHash string) ScanState {
+	return ScanState{ViewHash: viewHash, Candidates: map[string]ScanStateCandidate{}, used: map[string]bool{}}
+}
+
+// LoadScanState reads the scan state at path, returning an empty state if it is stale.
+func LoadScanState(path string, viewHash string) (state ScanState, stale bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewScanState(viewHash), false, nil
+	} else if err != nil {
+		return ScanState{}, false, errors.Join(errors.New("failed to read scan state"), err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return ScanState{}, false, errors.Join(errors.New("failed to parse scan state"), err)
+	}
+	if state.ViewHash != viewHash {
+		return NewScanState(viewHash), true, nil
+	}
+	if state.Candidates == nil {
+		state.Candidates = map[string]ScanStateCandidate{}
+	}
+	state.used = map[string]bool{}
+	return state, false, nil
+}
+
+// Save atomically writes the candidates used in this run to path.
+func (s ScanState) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	pruned := ScanState{ViewHash: s.ViewHash, Candidates: make(map[string]ScanStateCandidate, len(s.used))}
+	for key := range s.used {
+		if stored, ok := s.Candidates[key]; ok {
+			pruned.Candidates[key] = stored
+		}
+	}
+	data, err := json.MarshalIndent(pruned, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Get returns the stored results of the candidate reviewed from the given texts.
+func (s ScanState) Get(texts ...string) (map[string]CandidateQuestionResult, map[string]CandidateTextQuestionResult, bool) {
+	key := hashTexts(texts)
+	stored, ok := s.Candidates[key]
+	if !ok {
+		return nil, nil, false
+	}
+	s.used[key] = true
+	checklist := make(map[string]CandidateQuestionResult, len(stored.Checklist))
+	for key, p := range stored.Checklist {
+		if variance, ok := stored.ChecklistVariance[key]; ok {
+			checklist[key] = CandidateQuestionResult{numeric: true, value: p, variance: variance, reasoning: stored.ChecklistReasoning[key], evidence: stored.ChecklistEvidence[key]}
+		} else {
+			checklist[key] = CandidateQuestionResult{probability: p, reasoning: stored.ChecklistReasoning[key], evidence: stored.ChecklistEvidence[key]}
+		}
+	}
+	return checklist, stored.Questions, true
+}
+
+// Put stores the results of the candidate, which were made from the given texts.
+func (s ScanState) Put(checklist map[string]CandidateQuestionResult, questions map[string]CandidateTextQuestionResult, texts ...string) {
+	stored := ScanStateCandidate{
+		Checklist:          make(map[string]float64, len(checklist)),
+		ChecklistReasoning: make(map[string]string, len(checklist)),
+		Questions:          questions,
+	}
+	for key, r := range checklist {
+		stored.ChecklistReasoning[key] = r.Reasoning()
+		if len(r.Evidence()) > 0 {
+			if stored.ChecklistEvidence == nil {
+				stored.ChecklistEvidence = make(map[string][]string)
+			}
+			stored.ChecklistEvidence[key] = r.Evidence()
+		}
+		if r.IsNumeric() {
+			if stored.ChecklistVariance == nil {
+				stored.ChecklistVariance = make(map[string]float64)
+			}
+			stored.Checklist[key] = r.Value()
+			stored.ChecklistVariance[key] = r.Inconsistency()
+		} else {
+			stored.Checklist[key] = r.Probability()
+		}
+	}
+	key := hashTexts(texts)
+	s.Candidates[key] = stored
+	s.used[key] = true
+}
+
+// scanViewHash hashes everything that affects the model's answers for a view.
+func scanViewHash(view ConfigView, modelName string, numRepeats int) string {
+	viewPrompts := view.Prompts()
+	prompts := reviewPrompts{viewPrompts.ReviewTemplate, viewPrompts.QuestionTemplate, viewPrompts.JobDescription}
+	if prompts.ReviewTemplate == "" {
+		prompts.ReviewTemplate = simpleCandidateReviewTemplate
+	}
+	if prompts.QuestionTemplate == "" {
+		prompts.QuestionTemplate = simpleCandidateQuestionTemplate
+	}
+	checklist := checklistFromConfig(view)
+	// Boolean items are not aggregated, so only the aggregates of number and rating items are hashed.
+	var aggregates map[string]ChecklistAggregate
+	for k, q := range checklist {
+		if q.Type.IsNumeric() {
+			if aggregates == nil {
+				aggregates = make(map[string]ChecklistAggregate)
+			}
+			aggregates[k] = q.Aggregate
+		}
+	}
+	data, _ := json.Marshal(struct {
+		Checklist         map[string]string
+		Questions         map[string]string
+		OriginalQuestions map[string]string
+		Prompts           reviewPrompts
+		ModelName         string
+		NumRepeats        int
+		Aggregates        map[string]ChecklistAggregate `json:",omitempty"`
+	}{checklistPrompts(checklist), questionPrompts(questionsFromConfig(view, false)), questionPrompts(questionsFromConfig(view, true)), prompts, modelName, numRepeats, aggregates})
+	return hashText(string(data))
+}
+
+// reviewPrompts are the hashed prompt settings, so their field names must not change.
+type reviewPrompts struct {
+	ReviewTemplate   string
+	QuestionTemplate string
+	JobDescription   string
+}
+
+// hashTexts returns a hash of all of the texts, or of the single text if they are all the same.
+func hashTexts(texts []string) string {
+	if len(texts) == 0 || !slices.ContainsFunc(texts, func(t string) bool { return t != texts[0] }) {
+		return hashText(strings.Join(texts[:min(len(texts), 1)], ""))
+	}
+	return hashText(strings.Join(texts, "\x00"))
+}
+
+// hashText returns the hex encoded SHA-256 hash of the text.
+func hashText(text string) string {
+	hash := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
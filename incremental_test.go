@@ -0,0 +1,48 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestScanStateSavePrunesUnusedCandidates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	result := map[string]CandidateQuestionResult{"python": {probability: 1}}
+	answers := map[string]CandidateTextQuestionResult{}
+
+	state := NewScanState("hash")
+	state.Put(result, answers, "kept")
+	state.Put(result, answers, "removed")
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next run only sees one of the CVs again, and reviews a new one.
+	state, _, err := LoadScanState(path, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := state.Get("kept"); !ok {
+		t.Fatal("Get() found no stored result for a saved candidate")
+	}
+	state.Put(result, answers, "new")
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err = LoadScanState(path, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0)
+	for key := range state.Candidates {
+		got = append(got, key)
+	}
+	want := []string{hashText("kept"), hashText("new")}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("saved candidates = %v, want %v", got, want)
+	}
+}
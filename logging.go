@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/MatusOllah/slogcolor"
+	"github.com/fatih/color"
+)
+
+// LogFormat is the format that logs are written in.
+type LogFormat string
+
+const (
+	// LogFormatColor is coloured text for reading in a terminal.
+	LogFormatColor LogFormat = "color"
+	// LogFormatText is plain key=value text, which is easy to grep.
+	LogFormatText LogFormat = "text"
+	// LogFormatJSON is one JSON object per line, for shipping to a log aggregator.
+	LogFormatJSON LogFormat = "json"
+)
+
+// logFlags are the flags that control logging, which every command accepts.
+type logFlags struct {
+	debug  *bool
+	format *string
+	file   *string
+}
+
+// addLogFlags registers the logging flags with fs.
+func addLogFlags(fs *flag.FlagSet) logFlags {
+	return logFlags{
+		debug:  fs.Bool("d", false, "if specified, enables debug logging"),
+		format: fs.String("log-format", "", "the format of the logs, color, text or json (defaults to color, or text if -log-file is specified)"),
+		file:   fs.String("log-file", "", "if specified, logs are appended to this file instead of being written to stderr"),
+	}
+}
+
+// newLogger creates the logger described by the flags, exiting if they are invalid.
+func (f logFlags) newLogger(stderr io.Writer, secrets ...string) *slog.Logger {
+	out := stderr
+	if *f.file != "" {
+		file, err := os.OpenFile(*f.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		out = file
+	}
+	logger, err := newLogger(out, f.logFormat(), *f.debug, secrets...)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid -log-format: %v\n", err)
+		os.Exit(1)
+	}
+	return logger
+}
+
+// logFormat returns the format chosen by the flags.
+func (f logFlags) logFormat() LogFormat {
+	switch {
+	case *f.format != "":
+		return LogFormat(*f.format)
+	case *f.file != "":
+		return LogFormatText
+	default:
+		return LogFormatColor
+	}
+}
+
+// newLogger creates a logger that writes to out, redacting the secrets.
+func newLogger(out io.Writer, format LogFormat, debug bool, secrets ...string) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	var handler slog.Handler
+	switch format {
+	case LogFormatColor:
+		opts := slogcolor.DefaultOptions
+		opts.Level = level
+		opts.MsgColor = color.New(color.FgMagenta)
+		opts.SrcFileMode = slogcolor.Nop
+		handler = slogcolor.NewHandler(out, opts)
+	case LogFormatText:
+		handler = slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level})
+	default:
+		return nil, fmt.Errorf("unknown log format %q, must be color, text or json", format)
+	}
+	return slog.New(newRedactingHandler(handler, secrets...)), nil
+}
+
+const redactedPlaceholder = "[REDACTED]"
+
+// newRedactingHandler wraps a handler so that the secrets are never logged.
+func newRedactingHandler(handler slog.Handler, secrets ...string) slog.Handler {
+	nonEmpty := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return handler
+	}
+	return &redactingHandler{handler: handler, secrets: nonEmpty}
+}
+
+type redactingHandler struct {
+	handler slog.Handler
+	secrets []string
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactString(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return &redactingHandler{handler: h.handler.WithAttrs(redacted), secrets: h.secrets}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{handler: h.handler.WithGroup(name), secrets: h.secrets}
+}
+
+func (h *redactingHandler) redactString(s string) string {
+	for _, secret := range h.secrets {
+		s = strings.ReplaceAll(s, secret, redactedPlaceholder)
+	}
+	return s
+}
+
+// redactAttr redacts an attribute, recursing into groups.
+func (h *redactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(h.redactString(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redactAttr(ga)
+		}
+		a.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		formatted := fmt.Sprintf("%+v", a.Value.Any())
+		if r := h.redactString(formatted); r != formatted {
+			a.Value = slog.StringValue(r)
+		}
+	}
+	return a
+}
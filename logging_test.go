@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	cases := []struct {
+		name   string
+		secret string
+	}{
+		{"api key", "sk-proj-abcdefghijklmnop"},
+		{"short token", "tok1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := slog.New(newRedactingHandler(slog.NewJSONHandler(buf, nil), c.secret))
+			logger.With("key", c.secret).Info("using "+c.secret, "err", "bad key "+c.secret)
+			if strings.Contains(buf.String(), c.secret) {
+				t.Errorf("secret %q was logged: %s", c.secret, buf.String())
+			}
+			if strings.Count(buf.String(), redactedPlaceholder) != 3 {
+				t.Errorf("want 3 redactions, got: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestLogFlagsFormat(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		want LogFormat
+	}{
+		{"default", nil, LogFormatColor},
+		{"log file", []string{"-log-file", "cvscan.log"}, LogFormatText},
+		{"explicit format", []string{"-log-format", "json"}, LogFormatJSON},
+		{"explicit format with log file", []string{"-log-file", "cvscan.log", "-log-format", "color"}, LogFormatColor},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags := addLogFlags(fs)
+			if err := fs.Parse(c.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := flags.logFormat(); got != c.want {
+				t.Errorf("logFormat() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	const secret = "sk-proj-abcdefghijklmnop"
+	cases := []struct {
+		format   LogFormat
+		wantLine string
+	}{
+		{LogFormatText, `level=INFO msg="Using key [REDACTED]" key=[REDACTED]`},
+		{LogFormatJSON, `"level":"INFO","msg":"Using key [REDACTED]","key":"[REDACTED]"}`},
+		{LogFormatColor, "Using key [REDACTED]"},
+	}
+	for _, c := range cases {
+		t.Run(string(c.format), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger, err := newLogger(buf, c.format, false, secret)
+			if err != nil {
+				t.Fatalf("newLogger() error = %v", err)
+			}
+			logger.Debug("Hidden unless debugging")
+			logger.Info("Using key "+secret, "key", secret)
+			out := buf.String()
+			if !strings.Contains(out, c.wantLine) || strings.Contains(out, secret) || strings.Contains(out, "Hidden") {
+				t.Errorf("log = %q, want one redacted info line containing %q", out, c.wantLine)
+			}
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	logger, err := newLogger(buf, LogFormatText, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("Shown when debugging")
+	if !strings.Contains(buf.String(), "level=DEBUG") {
+		t.Errorf("debug log = %q, want the debug line", buf.String())
+	}
+
+	if _, err := newLogger(buf, "xml", false); err == nil {
+		t.Error("newLogger() with an unknown format error = nil, want an error")
+	}
+}
+
+func TestLogFlagsLogFile(t *testing.T) {
+	const secret = "sk-proj-abcdefghijklmnop"
+	path := filepath.Join(t.TempDir(), "cvscan.log")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := addLogFlags(fs)
+	if err := fs.Parse([]string{"-log-file", path}); err != nil {
+		t.Fatal(err)
+	}
+	stderr := &bytes.Buffer{}
+	flags.newLogger(stderr, secret).Info("Using key " + secret)
+	flags.newLogger(stderr, secret).Info("Appended")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `msg="Using key [REDACTED]"`) || !strings.Contains(lines[1], "msg=Appended") {
+		t.Errorf("log file = %q, want both redacted lines as plain text", data)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("stderr = %q, want nothing as the logs went to the file", stderr.String())
+	}
+}
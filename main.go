@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"maps"
@@ -13,54 +15,137 @@ import (
 	"strings"
 	"time"
 
-	"github.com/MatusOllah/slogcolor"
-	"github.com/fatih/color"
+	"github.com/JoshPattman/jpf"
+	"github.com/mattn/go-isatty"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "suggest":
+			suggestMain(os.Args[2:])
+			return
+		case "serve":
+			serveMain(os.Args[2:])
+			return
+		}
+	}
+
 	numRepeats := flag.Int("r", 5, "number of repeats to run, higher is more accurate but costs more and is slower")
 	maxConcurrentConnections := flag.Int("c", 3, "maximum number of concurrent connections to the LLM API, higher is faster but will rate limit more easily")
-	apiKey := flag.String("k", "", "the openai api key, must always be specified")
+	apiKey := flag.String("k", "", "the openai api key (prefer the CVSCAN_API_KEY or OPENAI_API_KEY environment variables, a .env file, or -key-file, as flags are visible to other users)")
+	keyFile := flag.String("key-file", "", "path to a secrets file containing only the openai api key")
 	apiUrl := flag.String("u", "https://api.openai.com/v1/chat/completions", "the openai api url (or a url of any other openai-format api)")
 	modelName := flag.String("m", "gpt-4.1", "the name of the model to use for everything")
-	debugLevel := flag.Bool("d", false, "if specified, enables debug logging")
+	logFlags := addLogFlags(flag.CommandLine)
+	structuredOutput := flag.Bool("s", false, "if specified, uses the provider's structured output feature to force responses to match a JSON schema (the provider must support response_format json_schema)")
+	dryRun := flag.Bool("dry-run", false, "if specified, estimates the tokens and cost of the run without calling the LLM API")
+	maxCost := flag.Float64("max-cost", 0, "maximum amount of US dollars to spend, after which remaining work is cancelled and partial results are written (0 for no limit)")
+	maxTokens := flag.Int("max-tokens", 0, "maximum number of tokens to use, after which remaining work is cancelled and partial results are written (0 for no limit)")
+	inputPrice := flag.Float64("input-price", 0, "price in US dollars per million input tokens, overrides the built-in price for the model")
+	outputPrice := flag.Float64("output-price", 0, "price in US dollars per million output tokens, overrides the built-in price for the model")
+	dedup := flag.Bool("dedup", false, "if specified, candidates who submitted more than one CV are detected and only reviewed once")
+	dedupSimilarity := flag.Float64("dedup-similarity", 0.9, "how similar (from 0 to 1) the text of two CVs must be for them to be considered duplicates, set above 1 to only use exact text and email matches")
+	incremental := flag.Bool("incremental", false, "if specified, only candidates that are new or changed since the last run (or whose view has changed) are reviewed, and are merged with the stored results of the others")
+	textCacheDir := flag.String("text-cache", "./text_cache", "directory to cache the text extracted from PDFs in, so unchanged files are not parsed again (empty to disable)")
+	configPath := flag.String("config", "", "path to the config file (.json, .yaml, .yml or .toml), if not specified the single config.json, config.yaml, config.yml or config.toml in the current directory is used")
 	flag.Parse()
 
 	tAllstart := time.Now()
-	opts := slogcolor.DefaultOptions
-	if *debugLevel {
-		opts.Level = slog.LevelDebug
+	resolvedKey, keySource, keyErr := ResolveAPIKey(*apiKey, *keyFile, ".env")
+	// When stderr is a terminal, a progress bar is kept below the log lines.
+	var status *statusLine
+	var stderr io.Writer = os.Stderr
+	if isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd()) {
+		status = newStatusLine(os.Stderr)
+		stderr = status
 	}
-	opts.MsgColor = color.New(color.FgMagenta)
-	opts.SrcFileMode = slogcolor.Nop
-	logger := slog.New(slogcolor.NewHandler(os.Stderr, opts))
+	logger := logFlags.newLogger(stderr, resolvedKey)
 
-	if *apiKey == "" {
-		logger.Error("API key must be specified with -k")
+	if keyErr != nil {
+		logger.Error("Failed to find API key", "err", keyErr)
+		os.Exit(1)
+	}
+	if resolvedKey == "" && !*dryRun {
+		logger.Error("API key must be specified, with the CVSCAN_API_KEY or OPENAI_API_KEY environment variables, a .env file, -key-file, or -k")
 		os.Exit(1)
 	}
+	if resolvedKey != "" {
+		logger.Info("Using API key", "source", keySource)
+	}
+
+	price, knownPrice := PriceForModel(*modelName)
+	if *inputPrice > 0 || *outputPrice > 0 {
+		price = ModelPrice{InputPerMillion: *inputPrice, OutputPerMillion: *outputPrice}
+		knownPrice = true
+	}
+	if *maxCost > 0 && !knownPrice {
+		logger.Error("The price of the model is not known, specify it with -input-price and -output-price to use -max-cost", "model", *modelName)
+		os.Exit(1)
+	}
+	budget := Budget{
+		MaxTokens: *maxTokens,
+		MaxCost:   *maxCost,
+		Price:     price,
+	}
 
 	logger.Info("Reading config")
-	cfg, err := LoadConfig()
+	if *configPath == "" {
+		var err error
+		*configPath, err = FindConfigFile(".")
+		if err != nil {
+			logger.Error("Failed to find config", "err", err)
+			os.Exit(1)
+		}
+	}
+	cfg, err := LoadConfig(*configPath)
 	if err != nil {
 		logger.Error("Failed to load config", "err", err)
 		os.Exit(1)
 	}
 
+	var textCache *TextCache
+	if *textCacheDir != "" {
+		textCache, err = NewTextCache(*textCacheDir)
+		if err != nil {
+			logger.Error("Failed to open text cache", "err", err)
+			os.Exit(1)
+		}
+	}
+
 	logger.Info("Reading PDFs")
-	pdfs, err := readPDFsFromDir("./pdf")
+	pdfs, err := readPDFsFromDir("./pdf", textCache)
 	if err != nil {
 		logger.Error("Failed to read PDFs", "err", err)
 		os.Exit(1)
 	}
-	pdfNames := make([]string, 0, len(pdfs))
-	pdfContents := make([]string, 0, len(pdfs))
-	for k, v := range pdfs {
-		pdfNames = append(pdfNames, k)
-		pdfContents = append(pdfContents, v)
+	if textCache != nil {
+		logger.Info("Read PDFs", "from_cache", textCache.Hits, "extracted", textCache.Misses)
+	}
+	for _, path := range slices.Sorted(maps.Keys(pdfs)) {
+		if HasTooLittleText(pdfs[path]) {
+			logger.Warn("Very little text could be extracted from the CV, it may be a scanned image, so it will be reviewed on almost no text and never treated as a duplicate", "path", path)
+		}
+	}
+	groups := singleFileGroups(pdfs)
+	if *dedup {
+		logger.Info("Finding duplicate candidates")
+		groups = FindDuplicates(pdfs, *dedupSimilarity)
+	}
+	for _, g := range groups {
+		for _, d := range g.Duplicates {
+			logger.Info("Found duplicate candidate, only one copy will be reviewed", "path", d, "reviewed_path", g.Primary, "reason", g.Reasons[d])
+		}
+	}
+	if len(cfg.PIIRedaction) > 0 {
+		logger.Info("Redacting PII", "categories", cfg.PIIRedaction)
+	}
+	if cfg.Anonymise {
+		logger.Info("Anonymising resumes")
 	}
-	for i, path := range pdfNames {
-		logger.Debug("Loaded PDF", "index", i, "path", path)
+	candidates := NewCandidates(cfg, pdfs, groups)
+	for _, c := range candidates {
+		logger.Debug("Loaded PDF", "candidate_id", c.ID, "path", c.Path)
 	}
 
 	if err := os.MkdirAll("./result", os.ModePerm); err != nil {
@@ -76,41 +161,112 @@ func main() {
 	logger.Info("Saving text files")
 	for k, v := range pdfs {
 		name := filepath.Base(k)
-		err = WriteTextFile(fmt.Sprintf("./text/%s.txt", name), v)
+		err = WriteTextFileIfChanged(fmt.Sprintf("./text/%s.txt", name), v)
 		if err != nil {
 			logger.Error("Failed to write text file", "err", err, "file", name)
 			os.Exit(1)
 		}
 	}
 
+	if len(cfg.PIIRedaction) > 0 {
+		for _, c := range candidates {
+			// The redacted text is exactly what is sent to the LLM, and the mapping never leaves this machine.
+			err = WriteTextFileIfChanged(fmt.Sprintf("./text/%s.redacted.txt", c.ID), c.RedactedText)
+			if err == nil {
+				err = WritePIIMappingFile(fmt.Sprintf("./text/%s.pii.json", c.ID), c.PIIMapping)
+			}
+			if err != nil {
+				logger.Error("Failed to write redacted text", "err", err, "candidate_id", c.ID, "file", c.Name)
+				os.Exit(1)
+			}
+			logger.Debug("Redacted PII", "candidate_id", c.ID, "num_redacted", len(c.PIIMapping))
+		}
+	}
+	if cfg.Anonymise {
+		for _, c := range candidates {
+			err = WriteTextFileIfChanged(fmt.Sprintf("./text/%s.anonymised.txt", c.ID), c.ReviewedText)
+			if err != nil {
+				logger.Error("Failed to write anonymised text", "err", err, "candidate_id", c.ID, "file", c.Name)
+				os.Exit(1)
+			}
+			logger.Debug("Anonymised resume", "candidate_id", c.ID, "num_removed", len(c.Anonymised))
+		}
+		err = WriteAnonymisationReportFile("./result/anonymisation.csv", candidates)
+		if err != nil {
+			logger.Error("Failed to write anonymisation report", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	if *dryRun {
+		logger.Info("Estimating usage")
+		usage, err := estimateRunUsage(cfg, candidates, *numRepeats)
+		if err != nil {
+			logger.Error("Failed to estimate usage", "err", err)
+			os.Exit(1)
+		}
+		args := []any{
+			"input_tokens", usage.InputTokens,
+			"output_tokens", usage.OutputTokens,
+			"llm_calls", usage.SuccessfulCalls,
+		}
+		if knownPrice {
+			args = append(args, "estimated_cost_usd", fmt.Sprintf("%.4f", price.Cost(usage)))
+		} else {
+			logger.Warn("The price of the model is not known, specify it with -input-price and -output-price to estimate cost", "model", *modelName)
+		}
+		logger.Info("Estimated usage (cached responses are not taken into account)", args...)
+		return
+	}
+
 	logger.Info("Creating model builder")
-	modelBuilder, err := NewModelBuilder(*apiKey, *apiUrl, *modelName, *maxConcurrentConnections)
+	modelBuilder, err := NewModelBuilder(resolvedKey, *apiUrl, *modelName, *maxConcurrentConnections, budget, *structuredOutput)
 	if err != nil {
 		logger.Error("Failed to create model builder", "err", err)
 		os.Exit(1)
 	}
 
 	viewRunner := &viewRunner{
-		logger:       logger,
-		views:        cfg.Views,
-		modelBuilder: modelBuilder,
-		pdfNames:     pdfNames,
-		pdfContents:  pdfContents,
-		numRepeats:   *numRepeats,
+		logger:        logger,
+		views:         cfg.Views,
+		modelBuilder:  modelBuilder,
+		candidates:    candidates,
+		numRepeats:    *numRepeats,
+		modelName:     *modelName,
+		incremental:   *incremental,
+		reportOptions: CSVReportOptions{Duplicates: *dedup, ProfileFields: cfg.ProfileReportColumns(), Now: tAllstart},
+		progress:      NewProgress(),
+	}
+	reporter := &progressReporter{progress: viewRunner.progress, counter: modelBuilder.UsageCounter()}
+	if knownPrice {
+		reporter.price = &price
+	}
+	stopProgress := reporter.Start(logger, status)
+	var profileErr error
+	if cfg.Profile != nil {
+		profileErr = extractProfiles(logger, modelBuilder, candidates, viewRunner.progress)
+		if err := WriteProfilesFile("./result/profiles.json", candidates); err != nil {
+			stopProgress()
+			logger.Error("Failed to write profiles", "err", err)
+			os.Exit(1)
+		}
 	}
 	err = ParMapDo(
 		slices.Collect(maps.Keys(cfg.Views)),
 		viewRunner.runView,
 	)
-	if err != nil {
+	err = joinFailures(profileErr, err)
+	stopProgress()
+	if isBudgetExceeded(err) {
+		logger.Warn("Budget exceeded, some reports only contain partial results")
+	} else if err != nil {
 		logger.Error("Failed to review candidates", "err", err)
 		os.Exit(1)
 	}
 
 	counter := modelBuilder.UsageCounter()
 	usage := counter.Get()
-	logger.Info(
-		"Everything finished",
+	finishedArgs := []any{
 		"time_taken",
 		time.Since(tAllstart),
 		"input_tokens",
@@ -121,51 +277,224 @@ func main() {
 		usage.SuccessfulCalls,
 		"failed_requests",
 		usage.FailedCalls,
-	)
+	}
+	if knownPrice {
+		finishedArgs = append(finishedArgs, "cost_usd", fmt.Sprintf("%.4f", price.Cost(usage)))
+	}
+	logger.Info("Everything finished", finishedArgs...)
+}
+
+// extractProfiles extracts the profile of every candidate, logging failures.
+func extractProfiles(logger *slog.Logger, modelBuilder ModelBuilder, candidates []Candidate, progress *Progress) error {
+	// View names cannot contain brackets, so this never clashes with a view.
+	profileProgress := progress.View("(profiles)")
+	profileProgress.AddTotal(len(candidates))
+	profiles, err := ExtractProfiles(logger, modelBuilder, candidates, profileProgress)
+	for i := range candidates {
+		candidates[i].Profile = profiles[i]
+	}
+	if err == nil || isBudgetExceeded(err) {
+		return err
+	}
+	numFailed := 0
+	for _, p := range profiles {
+		if p == nil {
+			numFailed++
+		}
+	}
+	logger.Warn("Failed to extract some profiles, their candidates are reviewed without one", "num_failed", numFailed, "num_candidates", len(candidates), "err", err)
+	if errors.Is(err, ErrBudgetExceeded) {
+		return ErrBudgetExceeded
+	}
+	return nil
+}
+
+// estimateRunUsage estimates the total usage of running every view over every candidate.
+func estimateRunUsage(cfg Config, candidates []Candidate, numRepeats int) (jpf.Usage, error) {
+	total := jpf.Usage{}
+	if cfg.Profile != nil {
+		profileUsage, err := EstimateProfileUsage(candidates)
+		if err != nil {
+			return jpf.Usage{}, err
+		}
+		total = total.Add(profileUsage)
+	}
+	for _, view := range cfg.Views {
+		reviewUsage, err := EstimateReviewUsage(view.Prompts(), checklistFromConfig(view), candidates, numRepeats)
+		if err != nil {
+			return jpf.Usage{}, err
+		}
+		questionUsage, err := EstimateQuestionUsage(view.Prompts(), questionsFromConfig(view, false), candidates, false, numRepeats)
+		if err != nil {
+			return jpf.Usage{}, err
+		}
+		originalQuestionUsage, err := EstimateQuestionUsage(view.Prompts(), questionsFromConfig(view, true), candidates, true, numRepeats)
+		if err != nil {
+			return jpf.Usage{}, err
+		}
+		total = total.Add(reviewUsage).Add(questionUsage).Add(originalQuestionUsage)
+		if view.Tournament != nil {
+			comparisonUsage, err := EstimateComparisonUsage(view.Prompts(), comparisonCriteria(checklistFromConfig(view)), candidates, view.Tournament.TopN)
+			if err != nil {
+				return jpf.Usage{}, err
+			}
+			total = total.Add(comparisonUsage)
+		}
+	}
+	return total, nil
 }
 
 type viewRunner struct {
 	logger       *slog.Logger
 	modelBuilder ModelBuilder
 	views        map[string]ConfigView
-	pdfNames     []string
-	pdfContents  []string
+	candidates   []Candidate
 	numRepeats   int
+	modelName    string
+	incremental  bool
+	// reportOptions chooses the optional columns of the CSV reports.
+	reportOptions CSVReportOptions
+	// progress tracks the LLM calls of every view, and may be nil.
+	progress *Progress
 }
 
 func (v *viewRunner) runView(viewName string) error {
-	view := v.views[viewName]
 	tstart := time.Now()
-	checklist := checklistFromConfig(view)
 	viewLogger := v.logger.With("view_name", viewName)
-	result, err := ReviewCandidates(viewLogger, v.modelBuilder, checklist, v.pdfContents, v.numRepeats)
+	reports, reviewErr := v.reviewView(viewName)
+	if reviewErr != nil && !isBudgetExceeded(reviewErr) {
+		return reviewErr
+	}
+	err := WriteCandidateReportsAsCSVFile(fmt.Sprintf("./result/report_%s.csv", viewName), reports, Boolean, v.reportOptions)
+	if err != nil {
+		return err
+	}
+	err = WriteCandidateReportsAsCSVFile(fmt.Sprintf("./result/probabilities_%s.csv", viewName), reports, Probability, v.reportOptions)
 	if err != nil {
 		return err
 	}
-	questions := make(map[string]string)
-	for qk, qv := range view.SpecificQuestions {
-		questions[qk] = qv.Question
+	err = WriteCandidateReportsAsCSVFile(fmt.Sprintf("./result/inconsistency_%s.csv", viewName), reports, Inconsistency, v.reportOptions)
+	if err != nil {
+		return err
 	}
-	answers, err := AnswerQuestionsForCandidates(viewLogger, v.modelBuilder, questions, v.pdfContents)
+	err = WriteCandidateReportsAsJSONFile(fmt.Sprintf("./result/report_%s.json", viewName), viewName, reports)
 	if err != nil {
 		return err
 	}
-	reports := make([]CandidateReport, len(result))
-	for i := range result {
-		finalScore := 0.0
-		for key, cqc := range result[i] {
-			if !cqc.IsTrue() {
-				continue
+	err = WriteCandidateReportsAsHTMLFile(fmt.Sprintf("./result/report_%s.html", viewName), viewTitle(v.views[viewName], viewName), reports)
+	if err != nil {
+		return err
+	}
+	if reviewErr != nil {
+		viewLogger.Warn("Wrote partial results as the budget was exceeded", "num_reported", len(reports), "num_candidates", len(v.candidates))
+		return reviewErr
+	}
+	viewLogger.Info("Finished review", "time_taken", time.Since(tstart))
+	return nil
+}
+
+// reviewView reviews every candidate with the view, returning their reports ranked by score.
+func (v *viewRunner) reviewView(viewName string) ([]CandidateReport, error) {
+	view := v.views[viewName]
+	checklist := checklistFromConfig(view)
+	viewLogger := v.logger.With("view_name", viewName)
+
+	// In incremental mode, candidates that have already been reviewed under the same view are loaded from the state,
+	// and only the remaining ones are sent to the model.
+	result := make([]map[string]CandidateQuestionResult, len(v.candidates))
+	answers := make([]map[string]CandidateTextQuestionResult, len(v.candidates))
+	statePath := fmt.Sprintf("./result/state/%s.json", viewName)
+	state := NewScanState(scanViewHash(view, v.modelName, v.numRepeats))
+	if v.incremental {
+		var stale bool
+		var err error
+		state, stale, err = LoadScanState(statePath, state.ViewHash)
+		if err != nil {
+			return nil, err
+		}
+		if stale {
+			viewLogger.Info("The view has changed since the last run, every candidate will be reviewed again")
+		}
+		for i, c := range v.candidates {
+			result[i], answers[i], _ = state.Get(c.ReviewedText, c.RedactedText)
+		}
+	}
+	pending := make([]int, 0, len(v.candidates))
+	pendingCandidates := make([]Candidate, 0, len(v.candidates))
+	for i, c := range v.candidates {
+		if result[i] == nil {
+			pending = append(pending, i)
+			pendingCandidates = append(pendingCandidates, c)
+		}
+	}
+	if v.incremental {
+		viewLogger.Info("Found previously reviewed candidates", "num_reviewed", len(v.candidates)-len(pending), "num_to_review", len(pending))
+	}
+
+	questions := questionsFromConfig(view, false)
+	originalQuestions := questionsFromConfig(view, true)
+	viewProgress := v.progress.View(viewName)
+	viewProgress.AddTotal(numViewCalls(len(pending), len(checklist), questions, originalQuestions, v.numRepeats))
+	estimatedComparisons := 0
+	if view.Tournament != nil {
+		estimatedComparisons = numComparisons(min(view.Tournament.TopN, len(v.candidates)))
+		viewProgress.AddTotal(estimatedComparisons)
+	}
+
+	pendingResult, reviewErr := ReviewCandidates(viewLogger, v.modelBuilder, view.Prompts(), checklist, pendingCandidates, v.numRepeats, viewProgress)
+	if reviewErr != nil && !isBudgetExceeded(reviewErr) {
+		return nil, reviewErr
+	}
+	pendingAnswers, answerErr := AnswerQuestionsForCandidates(viewLogger, v.modelBuilder, view.Prompts(), questions, pendingCandidates, false, v.numRepeats, viewProgress)
+	if answerErr != nil && !isBudgetExceeded(answerErr) {
+		return nil, answerErr
+	}
+	// Questions that are explicitly allowed to see the original text are answered separately, so that anonymisation still applies to everything else.
+	var pendingOriginalAnswers []map[string]CandidateTextQuestionResult
+	var originalAnswerErr error
+	if len(originalQuestions) > 0 {
+		pendingOriginalAnswers, originalAnswerErr = AnswerQuestionsForCandidates(viewLogger, v.modelBuilder, view.Prompts(), originalQuestions, pendingCandidates, true, v.numRepeats, viewProgress)
+		if originalAnswerErr != nil && !isBudgetExceeded(originalAnswerErr) {
+			return nil, originalAnswerErr
+		}
+	}
+	budgetErr := joinFailures(reviewErr, answerErr, originalAnswerErr)
+	for j, i := range pending {
+		result[i] = pendingResult[j]
+		answers[i] = pendingAnswers[j]
+		if pendingOriginalAnswers != nil {
+			if answers[i] != nil && pendingOriginalAnswers[j] != nil {
+				maps.Copy(answers[i], pendingOriginalAnswers[j])
+			} else {
+				answers[i] = nil
 			}
-			finalScore += view.ScoreChecklist[key].Weight
 		}
-		reports[i] = CandidateReport{
-			FileName:   filepath.Base(v.pdfNames[i]),
-			FileLoc:    v.pdfNames[i],
-			Checklist:  result[i],
-			FinalScore: finalScore,
-			Questions:  answers[i],
+		if result[i] != nil && answers[i] != nil {
+			state.Put(result[i], answers[i], v.candidates[i].ReviewedText, v.candidates[i].RedactedText)
+		}
+	}
+	if v.incremental {
+		// The state is saved even if the budget ran out, so the finished candidates are not paid for again.
+		if err := state.Save(statePath); err != nil {
+			return nil, errors.Join(errors.New("failed to save scan state"), err)
+		}
+	}
+	reports := make([]CandidateReport, 0, len(result))
+	for i, c := range v.candidates {
+		if result[i] == nil || answers[i] == nil {
+			// The candidate was not finished before the budget ran out.
+			continue
 		}
+		reports = append(reports, CandidateReport{
+			CandidateID: c.ID,
+			FileName:    c.Name,
+			FileLoc:     c.Path,
+			Duplicates:  c.Duplicates,
+			Checklist:   rehydrateChecklist(result[i], c.PIIMapping),
+			FinalScore:  ChecklistScore(view.ScoreChecklist, result[i]),
+			Questions:   rehydrateAnswers(answers[i], c.PIIMapping),
+			Profile:     c.Profile,
+		})
 	}
 	sort.Slice(reports, func(i, j int) bool {
 		if reports[i].FinalScore > reports[j].FinalScore {
@@ -176,30 +505,88 @@ func (v *viewRunner) runView(viewName string) error {
 			return reports[i].FileName < reports[j].FileName
 		}
 	})
-	err = WriteCandidateReportsAsCSVFile(fmt.Sprintf("./result/report_%s.csv", viewName), reports, Boolean)
-	if err != nil {
-		return err
+	// The tournament only compares the candidates that were reported, so the estimate made before the review is corrected.
+	if view.Tournament != nil && budgetErr != nil {
+		viewProgress.AddTotal(-estimatedComparisons)
 	}
-	err = WriteCandidateReportsAsCSVFile(fmt.Sprintf("./result/probabilities_%s.csv", viewName), reports, Probability)
-	if err != nil {
-		return err
+	if view.Tournament != nil && budgetErr == nil {
+		viewProgress.AddTotal(numComparisons(min(view.Tournament.TopN, len(reports))) - estimatedComparisons)
+		reports, budgetErr = v.runTournament(viewLogger, view, reports, viewProgress)
 	}
-	err = WriteCandidateReportsAsCSVFile(fmt.Sprintf("./result/inconsistency_%s.csv", viewName), reports, Inconsistency)
-	if err != nil {
-		return err
+	return reports, budgetErr
+}
+
+// numViewCalls returns the number of LLM calls to review candidates with a view.
+func numViewCalls(candidates int, checklistItems int, questions map[string]SpecificQuestion, originalQuestions map[string]SpecificQuestion, numRepeats int) int {
+	calls := 0
+	if checklistItems > 0 {
+		calls += candidates * numRepeats
 	}
-	viewLogger.Info("Finished review", "time_taken", time.Since(tstart))
-	return nil
+	calls += candidates * numQuestionCalls(questions, numRepeats)
+	calls += candidates * numQuestionCalls(originalQuestions, numRepeats)
+	return calls
+}
+
+// rehydrateChecklist restores the PII placeholders in every checklist result.
+func rehydrateChecklist(results map[string]CandidateQuestionResult, mapping PIIMapping) map[string]CandidateQuestionResult {
+	if len(mapping) == 0 {
+		return results
+	}
+	rehydrated := make(map[string]CandidateQuestionResult, len(results))
+	for key, r := range results {
+		r.reasoning = mapping.Rehydrate(r.reasoning)
+		if r.evidence != nil {
+			evidence := make([]string, len(r.evidence))
+			for i, quote := range r.evidence {
+				evidence[i] = mapping.Rehydrate(quote)
+			}
+			r.evidence = evidence
+		}
+		rehydrated[key] = r
+	}
+	return rehydrated
+}
+
+// rehydrateAnswers restores the PII placeholders in the answers.
+func rehydrateAnswers(answers map[string]CandidateTextQuestionResult, mapping PIIMapping) map[string]CandidateTextQuestionResult {
+	if len(mapping) == 0 {
+		return answers
+	}
+	rehydrated := make(map[string]CandidateTextQuestionResult, len(answers))
+	for key, a := range answers {
+		if a.Votes != nil {
+			// The answers to multiple-choice questions are always one of the choices (or empty), so only the reasoning can contain placeholders.
+			a.Reasoning = mapping.Rehydrate(a.Reasoning)
+			rehydrated[key] = a
+			continue
+		}
+		rehydrated[key] = CandidateTextQuestionResult{
+			Reasoning: mapping.Rehydrate(a.Reasoning),
+			Answer:    mapping.Rehydrate(a.Answer),
+		}
+	}
+	return rehydrated
 }
 
-func checklistFromConfig(cfg ConfigView) map[string]string {
-	checklist := make(map[string]string)
+func checklistFromConfig(cfg ConfigView) map[string]ChecklistQuestion {
+	checklist := make(map[string]ChecklistQuestion)
 	for key, val := range cfg.ScoreChecklist {
-		checklist[key] = val.Question
+		checklist[key] = ChecklistQuestion{Question: val.Question, Type: val.Type, Aggregate: val.Aggregate}
 	}
 	return checklist
 }
 
+// questionsFromConfig returns the view's questions that use the original text or not.
+func questionsFromConfig(cfg ConfigView, useOriginalText bool) map[string]SpecificQuestion {
+	questions := make(map[string]SpecificQuestion)
+	for key, val := range cfg.SpecificQuestions {
+		if val.UseOriginalText == useOriginalText {
+			questions[key] = SpecificQuestion{Question: val.Question, Choices: val.Choices}
+		}
+	}
+	return questions
+}
+
 func listPDFs(dir string) ([]string, error) {
 	var pdfFiles []string
 
@@ -216,16 +603,17 @@ func listPDFs(dir string) ([]string, error) {
 	return pdfFiles, err
 }
 
-func readPDFsFromDir(dir string) (map[string]string, error) {
+// readPDFsFromDir extracts the text of every PDF in dir, keyed by path.
+func readPDFsFromDir(dir string, cache *TextCache) (map[string]string, error) {
 	fileNames, err := listPDFs(dir)
 	if err != nil {
 		return nil, err
 	}
 	result := make(map[string]string)
 	for _, fn := range fileNames {
-		text, err := GetTextFromPDFFile(fn)
+		text, err := cache.GetTextFromPDFFile(fn)
 		if err != nil {
-			return nil, err
+			return nil, errors.Join(fmt.Errorf("failed to read %s", fn), err)
 		}
 		result[fn] = text
 	}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRehydrateChecklist(t *testing.T) {
+	redacted, mapping := RedactPII("Contact alice@example.com\nSenior engineer at Acme.", []PIICategory{PIIEmail})
+	quote := "Contact [EMAIL_1]"
+	answers := map[string]responseItem[checklistAnswer]{"contact": {Reasoning: "r", Evidence: []string{quote}}}
+	if problems := evidenceProblems(redacted, answers); len(problems) > 0 {
+		t.Fatalf("evidence from the redacted text was rejected: %v", problems)
+	}
+
+	results := map[string]CandidateQuestionResult{
+		"contact": {probability: 1, reasoning: "Emails are given as [EMAIL_1].", evidence: []string{quote}},
+		"acme":    {probability: 1, reasoning: "Worked at Acme."},
+	}
+	got := rehydrateChecklist(results, mapping)
+	if r := got["contact"].Reasoning(); r != "Emails are given as alice@example.com." {
+		t.Errorf("reasoning = %q, want the email restored", r)
+	}
+	if e := got["contact"].Evidence(); !slices.Equal(e, []string{"Contact alice@example.com"}) {
+		t.Errorf("evidence = %q, want the email restored", e)
+	}
+	if r := got["acme"].Reasoning(); r != "Worked at Acme." || got["acme"].Evidence() != nil {
+		t.Errorf("result without placeholders = %+v, want it unchanged", got["acme"])
+	}
+	if results["contact"].evidence[0] != quote {
+		t.Errorf("rehydrateChecklist changed the original evidence to %q", results["contact"].evidence[0])
+	}
+	if got := rehydrateChecklist(results, PIIMapping{}); got["contact"].Reasoning() != results["contact"].Reasoning() {
+		t.Errorf("rehydrateChecklist with no mapping changed the reasoning to %q", got["contact"].Reasoning())
+	}
+}
+
+func TestNumViewCalls(t *testing.T) {
+	freeText := map[string]SpecificQuestion{"name": {Question: "What is their name?"}}
+	choice := map[string]SpecificQuestion{"level": {Question: "What level are they?", Choices: []string{"junior", "senior"}}, "name": {Question: "What is their name?"}}
+	cases := []struct {
+		name              string
+		checklistItems    int
+		questions         map[string]SpecificQuestion
+		originalQuestions map[string]SpecificQuestion
+		want              int
+	}{
+		{"checklist only", 5, nil, nil, 10 * 3},
+		{"free-text questions are asked once", 5, freeText, nil, 10*3 + 10},
+		{"multiple-choice questions are repeated", 5, choice, nil, 10*3 + 10*3},
+		{"original text questions are asked separately", 5, freeText, choice, 10*3 + 10 + 10*3},
+		{"questions only", 0, freeText, nil, 10},
+		{"nothing to ask", 0, nil, nil, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := numViewCalls(10, c.checklistItems, c.questions, c.originalQuestions, 3); got != c.want {
+				t.Errorf("numViewCalls() = %d, want %d", got, c.want)
+			}
+		})
+	}
+}
@@ -10,14 +10,14 @@ import (
 // ModelBuilder builds LLM models.
 type ModelBuilder interface {
 	// BuildCandidateReviewModel builds a model for candidate review, using the specified logger.
-	BuildCandidateReviewModel(*slog.Logger) jpf.Model
+	BuildCandidateReviewModel(logger *slog.Logger, schema map[string]any) jpf.Model
 	// UsageCounter returns the usage counter for this model builder.
 	UsageCounter() *jpf.UsageCounter
 }
 
 // NewModelBuilder tries to create a new ModelBuilder with the specified API key.
-// The model will use cache that is persisted to ./cache.gob and will limit maximum number of concurrent connections.
-func NewModelBuilder(apiKey string, apiURL string, modelName string, maxConcurrency int) (ModelBuilder, error) {
+// The model will use cache that is persisted to ./cache.gob and will limit maximum number of concurrent connections and the budget.
+func NewModelBuilder(apiKey string, apiURL string, modelName string, maxConcurrency int, budget Budget, structuredOutput bool) (ModelBuilder, error) {
 	cache, err := jpf.NewFilePersistCache("./cache.gob")
 	if err != nil {
 		return nil, err
@@ -29,6 +29,8 @@ func NewModelBuilder(apiKey string, apiURL string, modelName string, maxConcurre
 		concLimiter:  jpf.NewMaxConcurrentLimiter(maxConcurrency),
 		cache:        cache,
 		usageCounter: jpf.NewUsageCounter(),
+		budget:       newBudgetTracker(budget),
+		structured:   structuredOutput,
 	}, nil
 }
 
@@ -39,12 +41,21 @@ type simpleModelBuilder struct {
 	concLimiter  jpf.ConcurrentLimiter
 	cache        jpf.ModelResponseCache
 	usageCounter *jpf.UsageCounter
+	budget       *budgetTracker
+	structured   bool
 }
 
-func (mb *simpleModelBuilder) BuildCandidateReviewModel(logger *slog.Logger) jpf.Model {
-	model := jpf.NewOpenAIModel(mb.apiKey, mb.modelName, jpf.WithTemperature{X: 0}, jpf.WithURL{X: mb.apiUrl})
+func (mb *simpleModelBuilder) BuildCandidateReviewModel(logger *slog.Logger, schema map[string]any) jpf.Model {
+	opts := []jpf.OpenAIModelOpt{jpf.WithTemperature{X: 0}, jpf.WithURL{X: mb.apiUrl}}
+	if mb.structured && schema != nil {
+		opts = append(opts, jpf.WithJsonSchema{X: schema})
+	}
+	model := jpf.NewOpenAIModel(mb.apiKey, mb.modelName, opts...)
 	model = jpf.NewLoggingModel(model, jpf.NewSlogModelLogger(logger.Info, false))
 	model = jpf.NewRetryModel(model, 8, jpf.WithDelay{X: time.Second * 5})
+	if mb.budget.budget.IsLimited() {
+		model = newBudgetModel(model, mb.budget)
+	}
 	model = jpf.NewConcurrentLimitedModel(model, mb.concLimiter)
 	model = jpf.NewCachedModel(model, mb.cache)
 	model = jpf.NewUsageCountingModel(model, mb.usageCounter)
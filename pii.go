@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// PIICategory is a kind of PII that can be redacted from CVs.
+type PIICategory string
+
+const (
+	PIIEmail       PIICategory = "email"
+	PIIPhone       PIICategory = "phone"
+	PIIAddress     PIICategory = "address"
+	PIIDateOfBirth PIICategory = "date_of_birth"
+)
+
+// piiCategories are all the known categories, in the order they are redacted.
+var piiCategories = []PIICategory{PIIEmail, PIIDateOfBirth, PIIPhone, PIIAddress}
+
+// placeholderName is the name used in the placeholders of the category, such as EMAIL.
+func (c PIICategory) placeholderName() string {
+	return strings.ToUpper(string(c))
+}
+
+const datePatternText = `(?:\d{1,2}[/.\-]\d{1,2}[/.\-]\d{2,4}|\d{4}-\d{2}-\d{2}|\d{1,2}(?:st|nd|rd|th)?\s+(?i:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,?\s+\d{4}|(?i:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4})`
+
+var (
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d\s().\-]{7,}\d`)
+	// dateOfBirthPattern matches dates labelled as a date of birth.
+	dateOfBirthPattern = regexp.MustCompile(`(?i:\b(?:date\s+of\s+birth|d\.?o\.?b\.?|born(?:\s+on)?))\s*[:\-]?\s*(` + datePatternText + `)`)
+	addressPatterns    = []*regexp.Regexp{
+		// Street addresses, such as 221B Baker Street.
+		regexp.MustCompile(`\b\d{1,5}[A-Za-z]?,?\s+(?:[A-Z][A-Za-z'\-]+\s+){1,4}(?:Street|St|Road|Rd|Avenue|Ave|Lane|Ln|Drive|Dr|Boulevard|Blvd|Court|Ct|Way|Close|Place|Pl|Crescent|Terrace|Gardens|Square|Sq|Hill|Grove|Row|Mews)\b\.?`),
+		// UK postcodes, such as NW1 6XE.
+		regexp.MustCompile(`\b[A-Z]{1,2}\d[A-Z\d]?\s?\d[A-Z]{2}\b`),
+		// US states followed by a ZIP code, such as CA 94105.
+		regexp.MustCompile(`\b[A-Z]{2}\s+\d{5}(?:-\d{4})?\b`),
+	}
+	yearPattern = regexp.MustCompile(`^(?:19|20)\d{2}$`)
+	digitGroups = regexp.MustCompile(`\d+`)
+)
+
+// PIIMapping maps the placeholders in a redacted text to the values they replaced.
+type PIIMapping map[string]string
+
+// Rehydrate replaces any placeholders in the text with the values they replaced.
+func (m PIIMapping) Rehydrate(text string) string {
+	for placeholder, value := range m {
+		text = strings.ReplaceAll(text, placeholder, value)
+	}
+	return text
+}
+
+// WritePIIMappingFile writes the PII mapping to a JSON file.
+func WritePIIMappingFile(filename string, mapping PIIMapping) error {
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteTextFileIfChanged(filename, string(data))
+}
+
+// RedactPII replaces PII of the given categories in the text with placeholders.
+func RedactPII(text string, categories []PIICategory) (string, PIIMapping) {
+	mapping := make(PIIMapping)
+	for _, category := range piiCategories {
+		if !slices.Contains(categories, category) {
+			continue
+		}
+		placeholders := make(map[string]string)
+		placeholderFor := func(value string) string {
+			if p, ok := placeholders[value]; ok {
+				return p
+			}
+			p := fmt.Sprintf("[%s_%d]", category.placeholderName(), len(placeholders)+1)
+			placeholders[value] = p
+			mapping[p] = value
+			return p
+		}
+		switch category {
+		case PIIEmail:
+			text = emailPattern.ReplaceAllStringFunc(text, placeholderFor)
+		case PIIPhone:
+			text = phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+				if !looksLikePhoneNumber(match) {
+					return match
+				}
+				return placeholderFor(strings.TrimSpace(match))
+			})
+		case PIIDateOfBirth:
+			text = replaceSubmatch(text, dateOfBirthPattern, 1, placeholderFor)
+		case PIIAddress:
+			for _, pattern := range addressPatterns {
+				text = pattern.ReplaceAllStringFunc(text, placeholderFor)
+			}
+		}
+	}
+	return text, mapping
+}
+
+// looksLikePhoneNumber filters out number sequences such as date ranges.
+func looksLikePhoneNumber(s string) bool {
+	groups := digitGroups.FindAllString(s, -1)
+	numDigits := 0
+	allYears := true
+	for _, g := range groups {
+		numDigits += len(g)
+		if !yearPattern.MatchString(g) {
+			allYears = false
+		}
+	}
+	return numDigits >= 9 && numDigits <= 15 && !allYears
+}
+
+// replaceSubmatch replaces only the given group of each match of the pattern.
+func replaceSubmatch(text string, pattern *regexp.Regexp, group int, replace func(string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[2*group], loc[2*group+1]
+		if start < 0 {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(replace(text[start:end]))
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
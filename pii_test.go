@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestRedactPII(t *testing.T) {
+	all := []PIICategory{PIIEmail, PIIPhone, PIIAddress, PIIDateOfBirth}
+	cases := []struct {
+		name       string
+		text       string
+		categories []PIICategory
+		want       string
+	}{
+		{"nothing to redact", "Go developer since 2015", all, "Go developer since 2015"},
+		{"no categories", "alice@example.com", nil, "alice@example.com"},
+		{"email", "Email: alice@example.com", all, "Email: [EMAIL_1]"},
+		{"same email twice", "alice@example.com and alice@example.com, or bob@example.com", []PIICategory{PIIEmail}, "[EMAIL_1] and [EMAIL_1], or [EMAIL_2]"},
+		{"phone", "Phone: +44 7700 900123", all, "Phone: [PHONE_1]"},
+		{"date range is not a phone", "Acme 2015 - 2019 - 2023", all, "Acme 2015 - 2019 - 2023"},
+		{"date of birth", "Date of birth: 12/03/1990, joined 12/03/2015", all, "Date of birth: [DATE_OF_BIRTH_1], joined 12/03/2015"},
+		{"address", "221B Baker Street, London NW1 6XE", all, "[ADDRESS_1], London [ADDRESS_2]"},
+		{"only chosen categories", "alice@example.com +44 7700 900123", []PIICategory{PIIPhone}, "alice@example.com [PHONE_1]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, mapping := RedactPII(c.text, c.categories)
+			if got != c.want {
+				t.Errorf("RedactPII(%q) = %q, want %q", c.text, got, c.want)
+			}
+			if restored := mapping.Rehydrate(got); restored != c.text {
+				t.Errorf("Rehydrate(%q) = %q, want the original %q", got, restored, c.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JoshPattman/jpf"
+)
+
+// Progress tracks how many of the LLM calls of each view have completed.
+type Progress struct {
+	mu    sync.Mutex
+	start time.Time
+	// now returns the current time, and is only replaced in tests.
+	now   func() time.Time
+	views map[string]*ViewProgress
+}
+
+// ViewProgress tracks the LLM calls of one view. A nil ViewProgress tracks nothing.
+type ViewProgress struct {
+	progress *Progress
+	done     int
+	total    int
+}
+
+// NewProgress creates a progress tracker.
+func NewProgress() *Progress {
+	return newProgressWithClock(time.Now)
+}
+
+// newProgressWithClock creates a progress tracker that reads the time from now.
+func newProgressWithClock(now func() time.Time) *Progress {
+	return &Progress{start: now(), now: now, views: make(map[string]*ViewProgress)}
+}
+
+// View returns the progress of the named view, or nil if p is nil.
+func (p *Progress) View(name string) *ViewProgress {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.views[name]
+	if !ok {
+		v = &ViewProgress{progress: p}
+		p.views[name] = v
+	}
+	return v
+}
+
+// AddTotal adds calls to the number of LLM calls that the view will make.
+func (v *ViewProgress) AddTotal(calls int) {
+	if v == nil {
+		return
+	}
+	v.progress.mu.Lock()
+	defer v.progress.mu.Unlock()
+	v.total += calls
+}
+
+// Done records that one of the view's LLM calls has completed, whether or not it succeeded.
+func (v *ViewProgress) Done() {
+	if v == nil {
+		return
+	}
+	v.progress.mu.Lock()
+	defer v.progress.mu.Unlock()
+	v.done++
+}
+
+// ProgressSnapshot is the progress of every view at one point in time.
+type ProgressSnapshot struct {
+	CallsDone  int                             `json:"calls_done"`
+	CallsTotal int                             `json:"calls_total"`
+	Views      map[string]ViewProgressSnapshot `json:"views"`
+	// ETA is the estimated time until every call has completed, or zero if it cannot be estimated yet.
+	ETA time.Duration `json:"-"`
+	// ETASeconds is ETA in whole seconds, for JSON.
+	ETASeconds int `json:"eta_seconds,omitempty"`
+}
+
+// ViewProgressSnapshot is the progress of one view at one point in time.
+type ViewProgressSnapshot struct {
+	CallsDone  int `json:"calls_done"`
+	CallsTotal int `json:"calls_total"`
+}
+
+// Fraction returns how much of the run has completed, from 0 to 1.
+func (s ProgressSnapshot) Fraction() float64 {
+	if s.CallsTotal == 0 {
+		return 0
+	}
+	return min(float64(s.CallsDone)/float64(s.CallsTotal), 1)
+}
+
+// Snapshot returns the current progress.
+func (p *Progress) Snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := ProgressSnapshot{Views: make(map[string]ViewProgressSnapshot, len(p.views))}
+	for name, v := range p.views {
+		s.Views[name] = ViewProgressSnapshot{CallsDone: v.done, CallsTotal: v.total}
+		s.CallsDone += v.done
+		s.CallsTotal += v.total
+	}
+	if s.CallsDone > 0 && s.CallsDone < s.CallsTotal {
+		elapsed := p.now().Sub(p.start)
+		s.ETA = time.Duration(float64(elapsed) / float64(s.CallsDone) * float64(s.CallsTotal-s.CallsDone))
+		s.ETASeconds = int(s.ETA.Round(time.Second).Seconds())
+	}
+	return s
+}
+
+// progressReporter periodically reports the progress of a run, along with the tokens used so far.
+type progressReporter struct {
+	progress *Progress
+	counter  *jpf.UsageCounter
+	// price is the price of the model, or nil if it is not known.
+	price *ModelPrice
+}
+
+const (
+	progressBarInterval = 250 * time.Millisecond
+	progressLogInterval = 15 * time.Second
+	progressBarWidth    = 24
+)
+
+// Start reports progress until the returned function is called.
+func (r *progressReporter) Start(logger *slog.Logger, status *statusLine) (stop func()) {
+	interval := progressLogInterval
+	if status != nil {
+		interval = progressBarInterval
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastDone := -1
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			s := r.progress.Snapshot()
+			if status != nil {
+				status.Set(r.formatBar(s))
+			} else if s.CallsTotal > 0 && s.CallsDone != lastDone {
+				logger.Info("Progress", r.logArgs(s)...)
+			}
+			lastDone = s.CallsDone
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+		if status != nil {
+			status.Set("")
+		}
+	}
+}
+
+// formatBar formats the progress as a single line.
+func (r *progressReporter) formatBar(s ProgressSnapshot) string {
+	filled := int(s.Fraction() * progressBarWidth)
+	parts := []string{
+		fmt.Sprintf("[%s%s] %d/%d calls %d%%", strings.Repeat("█", filled), strings.Repeat("░", progressBarWidth-filled), s.CallsDone, s.CallsTotal, int(s.Fraction()*100)),
+	}
+	if s.ETA > 0 {
+		parts = append(parts, "ETA "+s.ETA.Round(time.Second).String())
+	}
+	if r.counter != nil {
+		usage := r.counter.Get()
+		tokens := fmt.Sprintf("%s in, %s out tokens", formatTokenCount(usage.InputTokens), formatTokenCount(usage.OutputTokens))
+		if r.price != nil {
+			tokens += fmt.Sprintf(", $%.2f", r.price.Cost(usage))
+		}
+		parts = append(parts, tokens)
+	}
+	views := make([]string, 0, len(s.Views))
+	for _, name := range slices.Sorted(maps.Keys(s.Views)) {
+		views = append(views, fmt.Sprintf("%s %d/%d", name, s.Views[name].CallsDone, s.Views[name].CallsTotal))
+	}
+	if len(views) > 0 {
+		parts = append(parts, strings.Join(views, ", "))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (r *progressReporter) logArgs(s ProgressSnapshot) []any {
+	args := []any{
+		"calls_done", s.CallsDone,
+		"calls_total", s.CallsTotal,
+		"percent", int(s.Fraction() * 100),
+	}
+	if s.ETA > 0 {
+		args = append(args, "eta", s.ETA.Round(time.Second))
+	}
+	if r.counter != nil {
+		usage := r.counter.Get()
+		args = append(args, "input_tokens", usage.InputTokens, "output_tokens", usage.OutputTokens)
+		if r.price != nil {
+			args = append(args, "cost_usd", fmt.Sprintf("%.4f", r.price.Cost(usage)))
+		}
+	}
+	return args
+}
+
+// formatTokenCount formats a number of tokens compactly, such as 950, 12.3k or 1.2M.
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
+
+// statusLine writes to a terminal, keeping a status line below everything else.
+type statusLine struct {
+	mu     sync.Mutex
+	out    io.Writer
+	status string
+}
+
+func newStatusLine(out io.Writer) *statusLine {
+	return &statusLine{out: out}
+}
+
+// clearLine moves the cursor to the start of the line and erases it.
+const clearLine = "\r\x1b[K"
+
+// defaultStatusWidth is the status line width when the terminal width is unknown.
+const defaultStatusWidth = 80
+
+// fitStatus cuts the status to fit on one line of the terminal.
+func (s *statusLine) fitStatus(status string) string {
+	width := defaultStatusWidth
+	if f, ok := s.out.(*os.File); ok {
+		if w, ok := terminalWidth(f); ok {
+			width = w
+		}
+	}
+	// The last column is left empty, as some terminals wrap as soon as it is written to.
+	runes := []rune(status)
+	if len(runes) >= width {
+		return string(runes[:max(width-1, 0)])
+	}
+	return status
+}
+
+func (s *statusLine) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != "" {
+		io.WriteString(s.out, clearLine)
+	}
+	n, err := s.out.Write(p)
+	if s.status != "" {
+		io.WriteString(s.out, s.status)
+	}
+	return n, err
+}
+
+// Set replaces the status line, removing it if status is empty.
+func (s *statusLine) Set(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status = s.fitStatus(status)
+	io.WriteString(s.out, clearLine+status)
+	s.status = status
+}
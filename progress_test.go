@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusLineFitsWidth(t *testing.T) {
+	// A buffer is not a terminal, so the status is cut to the default width.
+	var out bytes.Buffer
+	status := newStatusLine(&out)
+	status.Set(strings.Repeat("█", 200))
+	if got := len([]rune(status.status)); got != defaultStatusWidth-1 {
+		t.Errorf("status has %d characters, want %d", got, defaultStatusWidth-1)
+	}
+	status.Set("short")
+	if status.status != "short" {
+		t.Errorf("status = %q, want %q", status.status, "short")
+	}
+}
+
+// fakeClock is a clock for tests that only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestProgressSnapshot(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)}
+	progress := newProgressWithClock(clock.Now)
+	finance := progress.View("finance")
+	programmer := progress.View("programmer")
+	finance.AddTotal(10)
+	programmer.AddTotal(20)
+	programmer.AddTotal(10)
+
+	s := progress.Snapshot()
+	if s.CallsDone != 0 || s.CallsTotal != 40 || s.ETA != 0 || s.Fraction() != 0 {
+		t.Errorf("snapshot before any calls = %+v, want 0/40 calls and no ETA", s)
+	}
+
+	// 10 calls in 20 seconds leaves 30 calls, which should take another minute.
+	clock.now = clock.now.Add(20 * time.Second)
+	for range 4 {
+		finance.Done()
+	}
+	for range 6 {
+		programmer.Done()
+	}
+	s = progress.Snapshot()
+	if s.CallsDone != 10 || s.CallsTotal != 40 || s.Fraction() != 0.25 {
+		t.Errorf("snapshot = %d/%d calls (%v), want 10/40 (0.25)", s.CallsDone, s.CallsTotal, s.Fraction())
+	}
+	if s.ETA != time.Minute || s.ETASeconds != 60 {
+		t.Errorf("ETA = %v (%d seconds), want 1m0s", s.ETA, s.ETASeconds)
+	}
+	wantViews := map[string]ViewProgressSnapshot{"finance": {CallsDone: 4, CallsTotal: 10}, "programmer": {CallsDone: 6, CallsTotal: 30}}
+	if !maps.Equal(s.Views, wantViews) {
+		t.Errorf("views = %+v, want %+v", s.Views, wantViews)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	for range 30 {
+		programmer.Done()
+	}
+	s = progress.Snapshot()
+	if s.ETA != 0 || s.ETASeconds != 0 || s.Fraction() != 1 {
+		t.Errorf("snapshot once more calls than expected are done = %+v, want no ETA and a fraction of 1", s)
+	}
+}
+
+func TestNilViewProgress(t *testing.T) {
+	var progress *Progress
+	view := progress.View("finance")
+	view.AddTotal(3)
+	view.Done()
+	if view != nil {
+		t.Errorf("View() of a nil Progress = %v, want nil", view)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// candidateReportResponse is the JSON form of a CandidateReport.
+type candidateReportResponse struct {
+	CandidateID string   `json:"candidate_id"`
+	FileName    string   `json:"file_name"`
+	Duplicates  []string `json:"duplicates"`
+	FinalScore  float64  `json:"final_score"`
+	// TournamentRank and TournamentScore are only set for tournament candidates.
+	TournamentRank  int                                `json:"tournament_rank,omitempty"`
+	TournamentScore float64                            `json:"tournament_score,omitempty"`
+	Checklist       map[string]checklistResultResponse `json:"checklist"`
+	Questions       map[string]questionAnswerResponse  `json:"questions"`
+	// Profile is only set when the config extracts profiles.
+	Profile *CandidateProfile `json:"profile,omitempty"`
+}
+
+type checklistResultResponse struct {
+	Passed      bool    `json:"passed"`
+	Probability float64 `json:"probability"`
+	// Value is only set for number and rating items.
+	Value         *float64 `json:"value,omitempty"`
+	Inconsistency float64  `json:"inconsistency"`
+	Reasoning     string   `json:"reasoning"`
+	// Evidence is the quotes from the reviewed text that support the answer.
+	Evidence []string `json:"evidence"`
+}
+
+type questionAnswerResponse struct {
+	Answer    string `json:"answer"`
+	Reasoning string `json:"reasoning"`
+	// Votes is only set for multiple-choice questions, and counts how many repeats gave each choice.
+	Votes map[string]int `json:"votes,omitempty"`
+}
+
+func newCandidateReportResponses(reports []CandidateReport) []candidateReportResponse {
+	responses := make([]candidateReportResponse, len(reports))
+	for i, r := range reports {
+		responses[i] = candidateReportResponse{
+			CandidateID:     r.CandidateID,
+			FileName:        r.FileName,
+			Duplicates:      make([]string, len(r.Duplicates)),
+			FinalScore:      r.FinalScore,
+			TournamentRank:  r.TournamentRank,
+			TournamentScore: r.TournamentScore,
+			Checklist:       make(map[string]checklistResultResponse, len(r.Checklist)),
+			Questions:       make(map[string]questionAnswerResponse, len(r.Questions)),
+			Profile:         r.Profile,
+		}
+		// Files are stored in the job's directory, but callers only know them by the names they uploaded.
+		for j, d := range r.Duplicates {
+			responses[i].Duplicates[j] = filepath.Base(d)
+		}
+		for key, c := range r.Checklist {
+			result := checklistResultResponse{Passed: c.IsTrue(), Probability: c.Probability(), Inconsistency: c.Inconsistency(), Reasoning: c.Reasoning(), Evidence: c.Evidence()}
+			if result.Evidence == nil {
+				result.Evidence = []string{}
+			}
+			if c.IsNumeric() {
+				value := c.Value()
+				result.Value = &value
+			}
+			responses[i].Checklist[key] = result
+		}
+		for key, q := range r.Questions {
+			responses[i].Questions[key] = questionAnswerResponse{Answer: q.Answer, Reasoning: q.Reasoning, Votes: q.Votes}
+		}
+	}
+	return responses
+}
+
+// viewTitle returns the name a view is shown with, which is its pretty name if it has one.
+func viewTitle(view ConfigView, viewName string) string {
+	if view.PrettyName != "" {
+		return view.PrettyName
+	}
+	return viewName
+}
+
+// viewReportResponse is the JSON form of the ranked reports of a view.
+type viewReportResponse struct {
+	View       string                    `json:"view"`
+	Candidates []candidateReportResponse `json:"candidates"`
+}
+
+// WriteCandidateReportsAsJSONFile writes the ranked reports of a view to a JSON file.
+func WriteCandidateReportsAsJSONFile(filename string, viewName string, reports []CandidateReport) error {
+	data, err := json.MarshalIndent(viewReportResponse{View: viewName, Candidates: newCandidateReportResponses(reports)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteTextFile(filename, string(data))
+}
+
+// WriteCandidateReportsAsHTMLFile writes the ranked reports of a view to a standalone HTML file.
+func WriteCandidateReportsAsHTMLFile(filename string, title string, reports []CandidateReport) error {
+	var buf bytes.Buffer
+	if err := WriteCandidateReportsAsHTML(&buf, title, reports); err != nil {
+		return err
+	}
+	return WriteTextFile(filename, buf.String())
+}
+
+// WriteCandidateReportsAsHTML writes the ranked reports of a view as an HTML page.
+func WriteCandidateReportsAsHTML(w io.Writer, title string, reports []CandidateReport) error {
+	return htmlReportTemplate.Execute(w, struct {
+		Title      string
+		Candidates []candidateReportResponse
+	}{title, newCandidateReportResponses(reports)})
+}
+
+// humaniseKey turns a config key such as "python_exp" into words for display, as the web UI does.
+func humaniseKey(key string) string {
+	return strings.Join(strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '-' }), " ")
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"humanise": humaniseKey,
+	"formatScore": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	},
+	"formatValue": func(v *float64) string {
+		return strconv.FormatFloat(*v, 'f', 2, 64)
+	},
+	"inc": func(i int) int { return i + 1 },
+}).Parse(htmlReportTemplateText))
+
+const htmlReportTemplateText = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+section { border: 1px solid #ddd; border-radius: 6px; padding: 0.5rem 1rem; margin-bottom: 1rem; }
+table { border-collapse: collapse; width: 100%; }
+td, th { text-align: left; vertical-align: top; padding: 0.4rem; border-top: 1px solid #eee; }
+.pass { color: #186a3b; }
+.fail { color: #a93226; }
+.muted { color: #777; }
+blockquote { margin: 0.3rem 0; padding: 0.1rem 0.6rem; border-left: 3px solid #c9a227; background: #fdf8e4; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{ range $i, $c := .Candidates }}
+<section>
+<h2>{{ inc $i }}. {{ $c.FileName }} <span class="muted">(score {{ formatScore $c.FinalScore }}{{ if $c.TournamentRank }}, tournament rank {{ $c.TournamentRank }}{{ end }})</span></h2>
+{{ if $c.Duplicates }}<p class="muted">Also submitted: {{ range $j, $d := $c.Duplicates }}{{ if $j }}, {{ end }}{{ $d }}{{ end }}</p>{{ end }}
+{{ if $c.Checklist }}
+<table>
+<tr><th>Checklist item</th><th>Answer</th><th>Reasoning and evidence</th></tr>
+{{ range $key, $item := $c.Checklist }}
+<tr>
+<td>{{ humanise $key }}</td>
+<td>{{ if $item.Value }}{{ formatValue $item.Value }}{{ else if $item.Passed }}<span class="pass">&#10003; yes</span>{{ else }}<span class="fail">&#10007; no</span>{{ end }}</td>
+<td>{{ $item.Reasoning }}{{ range $item.Evidence }}<blockquote>{{ . }}</blockquote>{{ end }}</td>
+</tr>
+{{ end }}
+</table>
+{{ end }}
+{{ if $c.Questions }}
+<table>
+<tr><th>Question</th><th>Answer</th></tr>
+{{ range $key, $q := $c.Questions }}
+<tr><td>{{ humanise $key }}</td><td>{{ $q.Answer }}</td></tr>
+{{ end }}
+</table>
+{{ end }}
+</section>
+{{ end }}
+</body>
+</html>
+`
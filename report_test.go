@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// testReports are reports with evidence, without evidence, and with markup that must be escaped.
+func testReports() []CandidateReport {
+	return []CandidateReport{{
+		CandidateID: "c1",
+		FileName:    "alice.pdf",
+		FileLoc:     "pdf/alice.pdf",
+		FinalScore:  1,
+		Checklist: map[string]CandidateQuestionResult{
+			"python_exp": {probability: 1, reasoning: "Uses Python daily.", evidence: []string{"Five years of Python at Acme"}},
+			"degree":     {probability: 0, reasoning: "No degree is listed."},
+			"injection":  {probability: 1, reasoning: "Wrote <script>alert(1)</script>", evidence: []string{"<script>alert(2)</script>"}},
+		},
+	}}
+}
+
+func TestWriteCandidateReportsAsHTMLFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "report.html")
+	if err := WriteCandidateReportsAsHTMLFile(filename, "Backend <engineers>", testReports()); err != nil {
+		t.Fatalf("WriteCandidateReportsAsHTMLFile() error = %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+
+	// Each checklist item is a table row, so its evidence must be in the same row.
+	rows := make(map[string]string)
+	for _, row := range regexp.MustCompile(`(?s)<tr>\s*<td>(.*?)</td>(.*?)</tr>`).FindAllStringSubmatch(page, -1) {
+		rows[row[1]] = row[2]
+	}
+	if row := rows["python exp"]; !strings.Contains(row, "Uses Python daily.<blockquote>Five years of Python at Acme</blockquote>") {
+		t.Errorf("python exp row = %q, want the evidence quoted after the reasoning", row)
+	}
+	if row := rows["degree"]; !strings.Contains(row, "No degree is listed.") || strings.Contains(row, "<blockquote>") {
+		t.Errorf("degree row = %q, want the reasoning without evidence", row)
+	}
+	if strings.Contains(page, "<script>") || strings.Contains(page, "<engineers>") {
+		t.Errorf("page contains unescaped markup:\n%s", page)
+	}
+	for _, escaped := range []string{"&lt;script&gt;alert(1)&lt;/script&gt;", "<blockquote>&lt;script&gt;alert(2)&lt;/script&gt;</blockquote>", "Backend &lt;engineers&gt;"} {
+		if !strings.Contains(page, escaped) {
+			t.Errorf("page does not contain %q", escaped)
+		}
+	}
+}
+
+func TestWriteCandidateReportsAsJSONFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteCandidateReportsAsJSONFile(filename, "backend", testReports()); err != nil {
+		t.Fatalf("WriteCandidateReportsAsJSONFile() error = %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		View       string `json:"view"`
+		Candidates []struct {
+			CandidateID string `json:"candidate_id"`
+			Checklist   map[string]struct {
+				Reasoning string          `json:"reasoning"`
+				Evidence  json.RawMessage `json:"evidence"`
+			} `json:"checklist"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("reading the JSON: %v", err)
+	}
+	if report.View != "backend" || len(report.Candidates) != 1 || report.Candidates[0].CandidateID != "c1" {
+		t.Fatalf("report = %s, want the view and its one candidate", data)
+	}
+	checklist := report.Candidates[0].Checklist
+	if got := string(checklist["degree"].Evidence); got != "[]" {
+		t.Errorf("evidence without quotes = %s, want []", got)
+	}
+	var quotes []string
+	if err := json.Unmarshal(checklist["python_exp"].Evidence, &quotes); err != nil || len(quotes) != 1 || quotes[0] != "Five years of Python at Acme" {
+		t.Errorf("evidence = %s, want the quote", checklist["python_exp"].Evidence)
+	}
+	if got := checklist["injection"].Reasoning; got != "Wrote <script>alert(1)</script>" {
+		t.Errorf("reasoning = %q, want it unchanged in JSON", got)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+// terminalWidth cannot detect the width of a terminal on this platform, so always returns false.
+func terminalWidth(f *os.File) (int, bool) {
+	return 0, false
+}
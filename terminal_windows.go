@@ -0,0 +1,19 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// terminalWidth returns the number of columns of the console f is connected to.
+func terminalWidth(f *os.File) (int, bool) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(f.Fd()), &info); err != nil {
+		return 0, false
+	}
+	width := int(info.Window.Right-info.Window.Left) + 1
+	return width, width > 0
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pdfExtractorVersion must be bumped whenever GetTextFromPDFFile changes.
+const pdfExtractorVersion = 1
+
+// TextCache stores text extracted from files, keyed by content hash.
+type TextCache struct {
+	dir    string
+	Hits   int
+	Misses int
+	// extract extracts the text of a file that is not in the cache.
+	extract func(path string) (string, error)
+}
+
+// NewTextCache creates a text cache in dir, creating the directory if needed.
+func NewTextCache(dir string) (*TextCache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.Join(errors.New("failed to create text cache directory"), err)
+	}
+	return &TextCache{dir: dir, extract: GetTextFromPDFFile}, nil
+}
+
+// GetTextFromPDFFile returns the text of the PDF file, using the cache if possible.
+func (c *TextCache) GetTextFromPDFFile(path string) (string, error) {
+	if c == nil {
+		return GetTextFromPDFFile(path)
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return "", err
+	}
+	cachePath := filepath.Join(c.dir, fmt.Sprintf("%s-v%d.txt", hash, pdfExtractorVersion))
+	if data, err := os.ReadFile(cachePath); err == nil {
+		if text, ok := decodeTextCacheEntry(data); ok {
+			c.Hits++
+			return text, nil
+		}
+		// A corrupt entry, such as one truncated by a full disk, is extracted again and overwritten.
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", errors.Join(errors.New("failed to read text cache"), err)
+	}
+	text, err := c.extract(path)
+	if err != nil {
+		return "", err
+	}
+	c.Misses++
+	if err := c.writeEntry(hash, cachePath, text); err != nil {
+		return "", errors.Join(errors.New("failed to write text cache"), err)
+	}
+	return text, nil
+}
+
+// writeEntry writes the cache entry for text to cachePath via a temporary file.
+func (c *TextCache) writeEntry(hash string, cachePath string, text string) error {
+	f, err := os.CreateTemp(c.dir, hash+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(encodeTextCacheEntry(text))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), cachePath)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// encodeTextCacheEntry returns the contents of a cache entry for text.
+func encodeTextCacheEntry(text string) string {
+	hash := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:]) + "\n" + text
+}
+
+// decodeTextCacheEntry returns the text of a cache entry, or false if the entry is corrupt.
+func decodeTextCacheEntry(data []byte) (string, bool) {
+	wantHash, text, ok := strings.Cut(string(data), "\n")
+	if !ok {
+		return "", false
+	}
+	hash := sha256.Sum256([]byte(text))
+	return text, hex.EncodeToString(hash[:]) == wantHash
+}
+
+// hashFile returns the hex encoded SHA-256 hash of the contents of the file.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestTextCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewTextCache(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extractions := 0
+	cache.extract = func(path string) (string, error) {
+		extractions++
+		data, err := os.ReadFile(path)
+		return "extracted " + string(data), err
+	}
+	cv := filepath.Join(dir, "cv.pdf")
+	get := func(wantText string, wantHits int, wantMisses int) {
+		t.Helper()
+		text, err := cache.GetTextFromPDFFile(cv)
+		if err != nil {
+			t.Fatalf("GetTextFromPDFFile() error = %v", err)
+		}
+		if text != wantText {
+			t.Errorf("GetTextFromPDFFile() = %q, want %q", text, wantText)
+		}
+		if cache.Hits != wantHits || cache.Misses != wantMisses || extractions != wantMisses {
+			t.Errorf("hits = %d, misses = %d, extractions = %d, want %d hits and %d misses", cache.Hits, cache.Misses, extractions, wantHits, wantMisses)
+		}
+	}
+	writeCV := func(text string) {
+		t.Helper()
+		if err := os.WriteFile(cv, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeCV("first version")
+	get("extracted first version", 0, 1)
+	get("extracted first version", 1, 1)
+
+	// A renamed file with the same contents is still a hit.
+	renamed := filepath.Join(dir, "renamed.pdf")
+	if err := os.Rename(cv, renamed); err != nil {
+		t.Fatal(err)
+	}
+	cv = renamed
+	get("extracted first version", 2, 1)
+
+	writeCV("second version")
+	get("extracted second version", 2, 2)
+	get("extracted second version", 3, 2)
+
+	// Truncate every cache entry, as a full disk might.
+	entries, err := filepath.Glob(filepath.Join(dir, "cache", "*.txt"))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("cache entries = %q (%v), want 2", entries, err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(entry, data[:len(data)-3], 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get("extracted second version", 3, 3)
+	get("extracted second version", 4, 3)
+}
+
+func TestTextCacheConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	// Each serve job has its own cache on the same directory, and jobs may upload the same file at the same time.
+	const numCaches = 16
+	caches := make([]*TextCache, numCaches)
+	var extracting sync.WaitGroup
+	for i := range caches {
+		cache, err := NewTextCache(filepath.Join(dir, "cache"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache.extract = func(path string) (string, error) {
+			// Every cache misses before any of them writes the entry.
+			extracting.Done()
+			extracting.Wait()
+			data, err := os.ReadFile(path)
+			return "extracted " + string(data), err
+		}
+		caches[i] = cache
+	}
+	for round := range 20 {
+		cv := filepath.Join(dir, fmt.Sprintf("cv_%d.pdf", round))
+		if err := os.WriteFile(cv, []byte(cv), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		extracting.Add(numCaches)
+		errs := make([]error, numCaches)
+		var wg sync.WaitGroup
+		for i, cache := range caches {
+			wg.Go(func() {
+				text, err := cache.GetTextFromPDFFile(cv)
+				if err == nil && text != "extracted "+cv {
+					err = fmt.Errorf("text = %q, want %q", text, "extracted "+cv)
+				}
+				errs[i] = err
+			})
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			t.Fatalf("GetTextFromPDFFile() in round %d error = %v", round, err)
+		}
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(dir, "cache", "*.tmp")); len(leftover) > 0 {
+		t.Errorf("temporary files %q were left in the cache", leftover)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"slices"
+)
+
+// maxTournamentSize is the largest number of candidates in a tournament.
+const maxTournamentSize = 30
+
+// runTournament reranks the top candidates of the sorted reports with pairwise comparisons.
+func (v *viewRunner) runTournament(logger *slog.Logger, view ConfigView, reports []CandidateReport, progress *ViewProgress) ([]CandidateReport, error) {
+	n := min(view.Tournament.TopN, len(reports))
+	if n < 2 {
+		return reports, nil
+	}
+	byID := make(map[string]Candidate, len(v.candidates))
+	for _, c := range v.candidates {
+		byID[c.ID] = c
+	}
+	top := make([]Candidate, n)
+	for i := range n {
+		top[i] = byID[reports[i].CandidateID]
+	}
+	comparisons, err := CompareCandidates(logger, v.modelBuilder, view.Prompts(), comparisonCriteria(checklistFromConfig(view)), top, progress)
+	if isBudgetExceeded(err) {
+		logger.Warn("Budget exceeded during the tournament, the top candidates are ranked by score only")
+		return reports, err
+	} else if err != nil && len(comparisons) == 0 {
+		logger.Warn("Every comparison failed, the top candidates are ranked by score only", "err", err)
+		return reports, nil
+	} else if err != nil {
+		logger.Warn("Some comparisons failed, the top candidates are ranked on the comparisons that succeeded", "num_failed", numComparisons(n)-len(comparisons), "err", err)
+	}
+
+	wins := make([][]float64, n)
+	for i := range wins {
+		wins[i] = make([]float64, n)
+	}
+	for _, c := range comparisons {
+		loser := c.First
+		if c.Winner() == c.First {
+			loser = c.Second
+		}
+		wins[c.Winner()][loser]++
+	}
+	strengths := BradleyTerry(wins)
+
+	// Candidates with equal strength keep the order of their scores.
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case strengths[a] > strengths[b]:
+			return -1
+		case strengths[a] < strengths[b]:
+			return 1
+		default:
+			return 0
+		}
+	})
+	ranked := make([]CandidateReport, 0, len(reports))
+	for rank, i := range order {
+		r := reports[i]
+		r.TournamentRank = rank + 1
+		r.TournamentScore = math.Round(strengths[i]*1000) / 1000
+		ranked = append(ranked, r)
+	}
+	ranked = append(ranked, reports[n:]...)
+	logger.Info("Finished tournament", "num_candidates", n, "num_comparisons", len(comparisons))
+	return ranked, nil
+}
+
+// bradleyTerryIterations is the most iterations BradleyTerry runs for.
+const bradleyTerryIterations = 1000
+
+// BradleyTerry fits a Bradley–Terry model to pairwise wins, returning normalised strengths.
+func BradleyTerry(wins [][]float64) []float64 {
+	n := len(wins)
+	strengths := make([]float64, n)
+	for i := range strengths {
+		strengths[i] = 1 / float64(n)
+	}
+	if n < 2 {
+		return strengths
+	}
+	// This uses the minorisation-maximisation algorithm, where each step is guaranteed to improve the likelihood.
+	for range bradleyTerryIterations {
+		next := make([]float64, n)
+		total := 0.0
+		for i := range n {
+			won, denominator := 0.0, 0.0
+			for j := range n {
+				if i == j {
+					continue
+				}
+				won += wins[i][j] + 0.5
+				denominator += (wins[i][j] + wins[j][i] + 1) / (strengths[i] + strengths[j])
+			}
+			next[i] = won / denominator
+			total += next[i]
+		}
+		change := 0.0
+		for i := range next {
+			next[i] /= total
+			change = max(change, math.Abs(next[i]-strengths[i]))
+		}
+		strengths = next
+		if change < 1e-9 {
+			break
+		}
+	}
+	return strengths
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestBradleyTerry(t *testing.T) {
+	cases := []struct {
+		name string
+		wins [][]float64
+		want []float64
+	}{
+		{"no players", [][]float64{}, []float64{}},
+		{"one player", [][]float64{{0}}, []float64{1}},
+		{"even", [][]float64{{0, 2}, {2, 0}}, []float64{0.5, 0.5}},
+		// With the extra tie, the first player has 3.5 wins to 1.5, so strengths are in that ratio.
+		{"three to one", [][]float64{{0, 3}, {1, 0}}, []float64{0.7, 0.3}},
+		{"three way tie", [][]float64{{0, 1, 1}, {1, 0, 1}, {1, 1, 0}}, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := BradleyTerry(c.wins)
+			if len(got) != len(c.want) {
+				t.Fatalf("BradleyTerry() = %v, want %v", got, c.want)
+			}
+			for i := range got {
+				if math.Abs(got[i]-c.want[i]) > 1e-6 {
+					t.Fatalf("BradleyTerry() = %v, want %v", got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBradleyTerryOrdering(t *testing.T) {
+	// Player 2 beats everyone both times, player 0 beats player 1 both times, and player 3 never plays player 0.
+	wins := [][]float64{
+		{0, 2, 0, 0},
+		{0, 0, 0, 1},
+		{2, 2, 0, 2},
+		{0, 1, 0, 0},
+	}
+	got := BradleyTerry(wins)
+	total := 0.0
+	for _, s := range got {
+		if s <= 0 {
+			t.Errorf("BradleyTerry() = %v, want every strength above 0", got)
+		}
+		total += s
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("BradleyTerry() strengths sum to %v, want 1", total)
+	}
+	order := []int{0, 1, 2, 3}
+	slices.SortFunc(order, func(a, b int) int { return cmp.Compare(got[b], got[a]) })
+	if order[0] != 2 || order[1] != 0 {
+		t.Errorf("BradleyTerry() = %v, ranking %v, want player 2 then player 0 first", got, order)
+	}
+}
+
+func TestRunTournamentWithFailedComparison(t *testing.T) {
+	// Each resume is a rank, and the model prefers the lower rank, but fails to compare rank 3 with rank 1.
+	comparedRanks := regexp.MustCompile(`Candidate A resume:\s*rank(\d)\s*Candidate B resume:\s*rank(\d)`)
+	model := fakeModel{respond: func(prompt string) (string, error) {
+		m := comparedRanks.FindStringSubmatch(prompt)
+		if m == nil {
+			return "", errors.New("unexpected prompt")
+		}
+		if m[1] == "3" && m[2] == "1" {
+			return "", errors.New("500 internal server error")
+		}
+		return fmt.Sprintf(`{"candidate_a_is_better": {"reasoning": "r", "answer": %t}}`, m[1] < m[2]), nil
+	}}
+	candidates := make([]Candidate, 0)
+	reports := make([]CandidateReport, 0)
+	// The reports are sorted by score, which puts the candidates in the opposite order to the model's preference.
+	for _, rank := range []string{"3", "2", "1"} {
+		candidates = append(candidates, Candidate{ID: "id" + rank, ReviewedText: "rank" + rank})
+		reports = append(reports, CandidateReport{CandidateID: "id" + rank})
+	}
+	v := &viewRunner{modelBuilder: fakeModelBuilder{model}, candidates: candidates}
+	progress := NewProgress()
+	viewProgress := progress.View("dev")
+	viewProgress.AddTotal(numComparisons(len(reports)))
+	view := ConfigView{Tournament: &ConfigTournament{TopN: 3}}
+	ranked, err := v.runTournament(slog.New(slog.NewTextHandler(io.Discard, nil)), view, reports, viewProgress)
+	if err != nil {
+		t.Fatalf("runTournament() error = %v, want the failed comparison to be skipped", err)
+	}
+	got := make([]string, len(ranked))
+	for i, r := range ranked {
+		got[i] = r.CandidateID
+	}
+	if !slices.Equal(got, []string{"id1", "id2", "id3"}) {
+		t.Errorf("runTournament() ranked %v, want [id1 id2 id3]", got)
+	}
+	if s := progress.Snapshot(); s.Fraction() != 1 {
+		t.Errorf("progress = %v, want every comparison done", s.Fraction())
+	}
+}
+
+func TestRunTournamentWithEveryComparisonFailed(t *testing.T) {
+	cases := []struct {
+		name    string
+		failure error
+		wantErr error
+	}{
+		{"server errors", errors.New("500 internal server error"), nil},
+		{"budget exceeded", ErrBudgetExceeded, ErrBudgetExceeded},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			model := fakeModel{respond: func(prompt string) (string, error) { return "", c.failure }}
+			candidates := []Candidate{{ID: "a", ReviewedText: "a"}, {ID: "b", ReviewedText: "b"}, {ID: "c", ReviewedText: "c"}}
+			reports := []CandidateReport{{CandidateID: "a", FinalScore: 3}, {CandidateID: "b", FinalScore: 2}, {CandidateID: "c", FinalScore: 1}}
+			v := &viewRunner{modelBuilder: fakeModelBuilder{model}, candidates: candidates}
+			view := ConfigView{Tournament: &ConfigTournament{TopN: 3}}
+			ranked, err := v.runTournament(slog.New(slog.NewTextHandler(io.Discard, nil)), view, reports, NewProgress().View("dev"))
+			if !errors.Is(err, c.wantErr) || (err != nil && c.wantErr == nil) {
+				t.Fatalf("runTournament() error = %v, want %v", err, c.wantErr)
+			}
+			if !reflect.DeepEqual(ranked, reports) {
+				t.Errorf("runTournament() = %+v, want the reports ranked by score only", ranked)
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"sync"
+	"text/template"
 
 	"github.com/JoshPattman/jpf"
 )
@@ -17,7 +22,7 @@ func ParMapDo[T any](inputs []T, fn func(T) error) error {
 	return err
 }
 
-// ParMapRange runs fn for every integer from 0 to upTo-1 in parallel, returning the results or an error if any occurred.
+// ParMapRange runs fn for every integer from 0 to upTo-1 in parallel, returning the results and an error if any occurred.
 func ParMapRange[U any](upTo int, fn func(int) (U, error)) ([]U, error) {
 	inputs := make([]int, upTo)
 	for i := 0; i < upTo; i++ {
@@ -26,7 +31,7 @@ func ParMapRange[U any](upTo int, fn func(int) (U, error)) ([]U, error) {
 	return ParMap(inputs, fn)
 }
 
-// Run every input through fn in parallel, returning the results or an error if any occurred.
+// Run every input through fn in parallel, returning the results and an error if any occurred.
 func ParMap[T, U any](inputs []T, fn func(T) (U, error)) ([]U, error) {
 	results := make([]U, len(inputs))
 	errs := make([]error, len(inputs))
@@ -44,11 +49,115 @@ func ParMap[T, U any](inputs []T, fn func(T) (U, error)) ([]U, error) {
 		}(i, input)
 	}
 	wg.Wait()
-	errs = slices.DeleteFunc(errs, func(err error) bool { return err == nil })
-	if len(errs) > 0 {
-		return nil, errors.Join(errs...)
+	return results, joinFailures(errs...)
+}
+
+// failureList is the combined error of several independent operations.
+type failureList []error
+
+func (l failureList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (l failureList) Unwrap() []error {
+	return l
+}
+
+// joinFailures combines independent failures into one error, ignoring nils.
+func joinFailures(errs ...error) error {
+	errs = slices.DeleteFunc(slices.Clone(errs), func(err error) bool { return err == nil })
+	if len(errs) == 0 {
+		return nil
+	}
+	return failureList(errs)
+}
+
+// schemaProperty is a single named property of an object JSON schema.
+type schemaProperty struct {
+	Name   string
+	Schema any
+}
+
+// orderedProperties marshals to a JSON object with the properties in the order given.
+type orderedProperties []schemaProperty
+
+func (props orderedProperties) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, p := range props {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// objectSchema builds a strict JSON schema for an object.
+func objectSchema(properties ...schemaProperty) map[string]any {
+	required := make([]string, len(properties))
+	for i, p := range properties {
+		required[i] = p.Name
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           orderedProperties(properties),
+		"required":             required,
+		"additionalProperties": false,
 	}
-	return results, nil
+}
+
+// validatePromptTemplate checks that tmpl parses and renders with the example data.
+func validatePromptTemplate(tmpl string, example any) error {
+	t, err := template.New("prompt").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	return t.Execute(io.Discard, example)
+}
+
+// validateRepeatedPromptTemplate checks that a template renders repeats differently.
+func validateRepeatedPromptTemplate(tmpl string, first any, second any) error {
+	return validatePromptTemplateVaries(tmpl, first, second, "the template must use {{ .RepeatNumber }}, otherwise every repeat sends the same prompt and is answered from the cache")
+}
+
+// validateJobDescriptionPromptTemplate checks that a template renders the job description.
+func validateJobDescriptionPromptTemplate(tmpl string, first any, second any) error {
+	return validatePromptTemplateVaries(tmpl, first, second, "the template must use {{ .JobDescription }} as the view has a job description, otherwise it never reaches the model")
+}
+
+// validatePromptTemplateVaries checks that a template renders both examples differently.
+func validatePromptTemplateVaries(tmpl string, first any, second any, message string) error {
+	t, err := template.New("prompt").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	var firstText, secondText strings.Builder
+	if err := t.Execute(&firstText, first); err != nil {
+		return err
+	}
+	if err := t.Execute(&secondText, second); err != nil {
+		return err
+	}
+	if firstText.String() == secondText.String() {
+		return errors.New(message)
+	}
+	return nil
 }
 
 func wrapJsonDecoder[T, U any](dec jpf.ResponseDecoder[T, U]) jpf.ResponseDecoder[T, U] {
@@ -67,3 +176,11 @@ func wrapJsonDecoder[T, U any](dec jpf.ResponseDecoder[T, U]) jpf.ResponseDecode
 		},
 	)
 }
+
+// problemsError returns an error asking the model to fix the problems, or nil if there are none.
+func problemsError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the response had the following problems, fix them and respond with the complete JSON object again:\n- %s", strings.Join(problems, "\n- "))
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// jsonObjectKeys returns the keys of a JSON object in the order they are written.
+func jsonObjectKeys(t *testing.T, data json.RawMessage) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		t.Fatalf("%s is not a JSON object", data)
+	}
+	keys := make([]string, 0)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+// testSchema is the part of a JSON schema that the response schemas use.
+type testSchema struct {
+	Type                 string          `json:"type"`
+	Properties           json.RawMessage `json:"properties"`
+	Required             []string        `json:"required"`
+	AdditionalProperties *bool           `json:"additionalProperties"`
+	Items                json.RawMessage `json:"items"`
+	Enum                 []string        `json:"enum"`
+}
+
+// checkStrictSchema checks that every object in the schema requires all of its properties, in order, and allows no others,
+// as strict structured output rejects any other schema.
+func checkStrictSchema(t *testing.T, path string, data json.RawMessage) {
+	t.Helper()
+	var schema testSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	switch schema.Type {
+	case "object":
+		keys := jsonObjectKeys(t, schema.Properties)
+		if !slices.Equal(schema.Required, keys) {
+			t.Errorf("%s requires %q, want every property %q", path, schema.Required, keys)
+		}
+		if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+			t.Errorf("%s allows additional properties", path)
+		}
+		var properties map[string]json.RawMessage
+		if err := json.Unmarshal(schema.Properties, &properties); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range keys {
+			checkStrictSchema(t, path+"."+key, properties[key])
+		}
+	case "array":
+		checkStrictSchema(t, path+"[]", schema.Items)
+	}
+}
+
+// schemaProperties marshals a schema and returns the names of its properties in order, with their schemas.
+func schemaProperties(t *testing.T, schema any) ([]string, map[string]json.RawMessage) {
+	t.Helper()
+	object := decodeSchema(t, schema)
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(object.Properties, &properties); err != nil {
+		t.Fatal(err)
+	}
+	return jsonObjectKeys(t, object.Properties), properties
+}
+
+// decodeSchema marshals a schema and decodes it as a testSchema.
+func decodeSchema(t *testing.T, schema any) testSchema {
+	t.Helper()
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded testSchema
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestOrderedProperties(t *testing.T) {
+	data, err := json.Marshal(orderedProperties{{"reasoning", 1}, {"answer", "a"}, {"evidence", nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"reasoning":1,"answer":"a","evidence":null}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+	if data, _ := json.Marshal(orderedProperties{}); string(data) != "{}" {
+		t.Errorf("json.Marshal() of no properties = %s, want {}", data)
+	}
+}
+
+func TestResponseSchemasAreStrict(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema map[string]any
+	}{
+		{"object", objectSchema(schemaProperty{"z", map[string]any{"type": "string"}}, schemaProperty{"a", objectSchema()})},
+		{"review", reviewResponseSchema(map[string]ChecklistQuestion{"python": {Question: "Python?"}, "years": {Question: "Years?", Type: ChecklistNumber}})},
+		{"questions", questionsResponseSchema(map[string]SpecificQuestion{"level": {Question: "Level?", Choices: []string{"junior", "senior"}}, "name": {Question: "Name?"}})},
+		{"suggest", suggestViewResponseSchema()},
+		{"comparison", comparisonResponseSchema()},
+		{"profile", profileResponseSchema()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data, err := json.Marshal(c.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkStrictSchema(t, c.name, data)
+		})
+	}
+}
+
+func TestReviewResponseSchema(t *testing.T) {
+	checklist := map[string]ChecklistQuestion{
+		"python":     {Question: "Does the candidate know Python?"},
+		"years":      {Question: "How many years of experience?", Type: ChecklistNumber},
+		"leadership": {Question: "How strong is their leadership?", Type: ChecklistRating},
+		"degree":     {Question: "Do they have a degree?", Type: ChecklistBoolean},
+	}
+	keys, items := schemaProperties(t, reviewResponseSchema(checklist))
+	if want := []string{"degree", "leadership", "python", "years"}; !slices.Equal(keys, want) {
+		t.Errorf("checklist keys = %q, want %q", keys, want)
+	}
+	wantTypes := map[string]string{"python": "boolean", "degree": "boolean", "years": "number", "leadership": "integer"}
+	for key, wantType := range wantTypes {
+		keys, item := schemaProperties(t, items[key])
+		if want := []string{"reasoning", "evidence", "answer"}; !slices.Equal(keys, want) {
+			t.Errorf("%s keys = %q, want %q", key, keys, want)
+		}
+		if got := decodeSchema(t, item["answer"]).Type; got != wantType {
+			t.Errorf("%s answer type = %q, want %q", key, got, wantType)
+		}
+	}
+}
+
+func TestQuestionsResponseSchema(t *testing.T) {
+	questions := map[string]SpecificQuestion{
+		"name":  {Question: "What is their name?"},
+		"level": {Question: "What level are they?", Choices: []string{"junior", "mid", "senior"}},
+	}
+	keys, items := schemaProperties(t, questionsResponseSchema(questions))
+	if want := []string{"level", "name"}; !slices.Equal(keys, want) {
+		t.Errorf("question keys = %q, want %q", keys, want)
+	}
+	for key, wantEnum := range map[string][]string{"name": nil, "level": {"junior", "mid", "senior"}} {
+		keys, item := schemaProperties(t, items[key])
+		if want := []string{"reasoning", "answer"}; !slices.Equal(keys, want) {
+			t.Errorf("%s keys = %q, want %q", key, keys, want)
+		}
+		if got := decodeSchema(t, item["answer"]); got.Type != "string" || !slices.Equal(got.Enum, wantEnum) {
+			t.Errorf("%s answer = %+v, want a string with enum %q", key, got, wantEnum)
+		}
+	}
+}
@@ -0,0 +1,662 @@
+"use strict";
+
+// The CVScan web UI, a single page app on top of the HTTP API served by `cvscan serve`.
+// All text from CVs and the model is inserted with textContent, never as HTML.
+
+const app = document.getElementById("app");
+const tokenButton = document.getElementById("token-button");
+let pollTimer = null;
+
+// h creates an element with the given attributes and children.
+// Attributes starting with "on" are event listeners, and children may be strings, elements, or arrays of them.
+function h(tag, attrs = {}, ...children) {
+    const el = document.createElement(tag);
+    for (const [key, value] of Object.entries(attrs)) {
+        if (value === undefined || value === null || value === false) {
+            continue;
+        }
+        if (key.startsWith("on")) {
+            el.addEventListener(key.slice(2), value);
+        } else if (key === "class") {
+            el.className = value;
+        } else if (key in el && typeof value !== "string") {
+            el[key] = value;
+        } else {
+            el.setAttribute(key, value === true ? "" : value);
+        }
+    }
+    for (const child of children.flat()) {
+        if (child !== undefined && child !== null && child !== false) {
+            el.append(child instanceof Node ? child : String(child));
+        }
+    }
+    return el;
+}
+
+function getToken() {
+    return localStorage.getItem("cvscanToken") || "";
+}
+
+function askForToken() {
+    const token = prompt("This server requires an access token (the value of CVSCAN_SERVE_TOKEN):", getToken());
+    if (token !== null) {
+        localStorage.setItem("cvscanToken", token.trim());
+        route();
+    }
+}
+
+tokenButton.addEventListener("click", askForToken);
+
+class APIError extends Error {
+    constructor(message, status, body) {
+        super(message);
+        this.status = status;
+        this.body = body;
+    }
+}
+
+// api calls the HTTP API, returning the decoded JSON response.
+async function api(path, options = {}) {
+    const headers = new Headers(options.headers || {});
+    if (getToken()) {
+        headers.set("Authorization", "Bearer " + getToken());
+    }
+    const resp = await fetch(path, { ...options, headers });
+    if (resp.status === 401) {
+        tokenButton.hidden = false;
+        throw new APIError("An access token is required, use the button at the top right to set it.", 401);
+    }
+    const isJSON = (resp.headers.get("Content-Type") || "").startsWith("application/json");
+    const body = isJSON ? await resp.json() : await resp.blob();
+    if (!resp.ok && !(isJSON && body.errors)) {
+        throw new APIError((isJSON && body.error) || resp.statusText, resp.status, body);
+    }
+    return body;
+}
+
+// configPath joins keys into a path in the same format as the server's config errors, such as views.programmer.score_checklist.
+function configPath(...keys) {
+    let path = "";
+    for (const key of keys) {
+        if (/^[A-Za-z0-9_-]+$/.test(key)) {
+            path = path ? path + "." + key : key;
+        } else {
+            path += "[" + JSON.stringify(key) + "]";
+        }
+    }
+    return path;
+}
+
+function humanise(key) {
+    return key.replace(/[_-]+/g, " ");
+}
+
+// formatVotes shows how many repeats gave each choice of a multiple-choice question, most popular first.
+function formatVotes(votes) {
+    return Object.entries(votes).filter(([, n]) => n > 0).sort((a, b) => b[1] - a[1]).map(([choice, n]) => `${choice} ${n}`).join(", ");
+}
+
+// formatDates shows the dates of a role or qualification, leaving out the parts that are not known.
+function formatDates(start, end) {
+    if (!start && !end) {
+        return "";
+    }
+    return ` (${start || "?"} – ${end || "?"})`;
+}
+
+// renderProfile shows a candidate's extracted profile, leaving out the parts that were not in their CV.
+function renderProfile(profile) {
+    const contact = [profile.name, profile.email, profile.phone, profile.location].filter((v) => v).join(" · ");
+    const list = (title, items, show) => items && items.length > 0
+        ? [h("div", {}, h("strong", {}, title)), h("ul", {}, items.map((item) => h("li", {}, show(item))))]
+        : [];
+    return [
+        h("h4", {}, "Profile"),
+        contact ? h("div", {}, contact) : null,
+        ...list("Links", profile.links, (link) => link),
+        ...list("Roles", profile.roles, (r) => [r.title, r.employer].filter((v) => v).join(" at ") + formatDates(r.start_date, r.end_date)),
+        ...list("Education", profile.education, (e) => [[e.qualification, e.field].filter((v) => v).join(" in "), e.institution].filter((v) => v).join(", ") + formatDates("", e.end_date)),
+        profile.skills && profile.skills.length > 0 ? h("div", {}, h("strong", {}, "Skills: "), profile.skills.join(", ")) : null,
+        profile.languages && profile.languages.length > 0 ? h("div", {}, h("strong", {}, "Languages: "), profile.languages.join(", ")) : null,
+    ];
+}
+
+// formatValue shows the answer to a number or rating item, which may be a mean of several repeats, to at most two decimal places.
+function formatValue(value) {
+    return String(Math.round(value * 100) / 100);
+}
+
+function statusBadge(status) {
+    return h("span", { class: "status " + status }, status);
+}
+
+function progressBar(done, total) {
+    const percent = total > 0 ? Math.round((done / total) * 100) : 0;
+    return h("div", { class: "progress", title: `${done} of ${total}` }, h("div", { style: `width: ${percent}%` }));
+}
+
+// jobProgressBar shows the LLM calls a job has completed, or the views it has finished if it has not started calling the model.
+function jobProgressBar(job) {
+    const p = job.progress;
+    if (job.status === "done") {
+        return progressBar(1, 1);
+    }
+    if (p && p.calls_total > 0) {
+        return progressBar(p.calls_done, p.calls_total);
+    }
+    return progressBar(job.views_done, job.views.length);
+}
+
+function formatDuration(seconds) {
+    const m = Math.floor(seconds / 60);
+    return m > 0 ? `${m}m ${seconds % 60}s` : `${seconds}s`;
+}
+
+function jobProgressText(job) {
+    const p = job.progress;
+    let text = `${job.views_done} of ${job.views.length} views finished`;
+    if (p && p.calls_total > 0) {
+        text += `, ${p.calls_done} of ${p.calls_total} LLM calls done`;
+    }
+    if (p && p.eta_seconds && job.status === "running") {
+        text += `, about ${formatDuration(p.eta_seconds)} left`;
+    }
+    return text;
+}
+
+function showError(err) {
+    app.replaceChildren(h("div", { class: "card error" }, err.message || String(err)));
+}
+
+async function downloadCSV(jobID, view, mode) {
+    const blob = await api(`/api/jobs/${jobID}/results/${encodeURIComponent(view)}?format=csv&mode=${mode}`);
+    const a = h("a", { href: URL.createObjectURL(blob), download: `${mode}_${view}.csv` });
+    a.click();
+    URL.revokeObjectURL(a.href);
+}
+
+// ---- New scan ----
+
+async function renderScan() {
+    const { views } = await api("/api/views");
+    let files = [];
+    const fileList = h("ul");
+    const startButton = h("button", { disabled: true }, "Start scan");
+    const status = h("p", { class: "error" });
+
+    const updateFiles = () => {
+        fileList.replaceChildren(...files.map((f, i) =>
+            h("li", {}, f.name + " ", h("button", {
+                class: "secondary", onclick: () => {
+                    files.splice(i, 1);
+                    updateFiles();
+                }
+            }, "Remove"))));
+        startButton.disabled = files.length === 0;
+    };
+    const addFiles = (list) => {
+        for (const f of list) {
+            if (f.name.toLowerCase().endsWith(".pdf")) {
+                files.push(f);
+            } else {
+                status.textContent = `${f.name} is not a PDF and was skipped.`;
+            }
+        }
+        updateFiles();
+    };
+
+    const input = h("input", { type: "file", multiple: true, accept: ".pdf,application/pdf", hidden: true, onchange: () => addFiles(input.files) });
+    const drop = h("div", {
+        class: "dropzone",
+        onclick: () => input.click(),
+        ondragover: (e) => {
+            e.preventDefault();
+            drop.classList.add("dragging");
+        },
+        ondragleave: () => drop.classList.remove("dragging"),
+        ondrop: (e) => {
+            e.preventDefault();
+            drop.classList.remove("dragging");
+            addFiles(e.dataTransfer.files);
+        },
+    }, "Drop CVs here, or click to choose PDF files");
+
+    const viewChecks = views.map((v) => h("input", { type: "checkbox", checked: true, value: v.name }));
+    startButton.addEventListener("click", async () => {
+        const selected = viewChecks.filter((c) => c.checked).map((c) => c.value);
+        if (selected.length === 0) {
+            status.textContent = "Choose at least one view.";
+            return;
+        }
+        const form = new FormData();
+        files.forEach((f) => form.append("files", f));
+        selected.forEach((v) => form.append("views", v));
+        startButton.disabled = true;
+        try {
+            const job = await api("/api/jobs", { method: "POST", body: form });
+            location.hash = "#/jobs/" + job.id;
+        } catch (err) {
+            status.textContent = err.message;
+            startButton.disabled = false;
+        }
+    });
+
+    app.replaceChildren(
+        h("h2", {}, "New scan"),
+        h("div", { class: "card" }, h("h3", {}, "1. Upload CVs"), drop, input, fileList),
+        h("div", { class: "card" },
+            h("h3", {}, "2. Choose views"),
+            views.length === 0 ? h("p", { class: "muted" }, "There are no views yet, create one on the Views page.") : null,
+            views.map((v, i) => h("label", { class: "row" }, viewChecks[i], v.pretty_name || v.name,
+                h("span", { class: "muted" }, ` (${v.score_checklist.length} checklist items, ${v.specific_questions.length} questions)`)))),
+        h("div", { class: "row" }, startButton, status),
+    );
+}
+
+// ---- Scans ----
+
+async function renderJobs() {
+    const { jobs } = await api("/api/jobs");
+    jobs.reverse();
+    const rows = jobs.map((job) => h("tr", { class: "clickable", onclick: () => location.hash = "#/jobs/" + job.id },
+        h("td", {}, new Date(job.created_at).toLocaleString()),
+        h("td", {}, job.files.length),
+        h("td", {}, job.views.join(", ")),
+        h("td", {}, statusBadge(job.status)),
+        h("td", {}, jobProgressBar(job))));
+    app.replaceChildren(
+        h("h2", {}, "Scans"),
+        h("div", { class: "card" }, jobs.length === 0
+            ? h("p", { class: "muted" }, "No scans yet. ", h("a", { href: "#/scan" }, "Start one."))
+            : h("table", {},
+                h("thead", {}, h("tr", {}, h("th", {}, "Started"), h("th", {}, "CVs"), h("th", {}, "Views"), h("th", {}, "Status"), h("th", {}, "Progress"))),
+                h("tbody", {}, rows))),
+    );
+    if (jobs.some((j) => j.status === "queued" || j.status === "running")) {
+        pollTimer = setTimeout(route, 2000);
+    }
+}
+
+async function renderJob(id) {
+    const job = await api(`/api/jobs/${id}`);
+    const summary = h("div", { class: "card" },
+        h("div", { class: "row" }, h("h2", { style: "margin: 0" }, "Scan " + job.id), statusBadge(job.status)),
+        h("p", { class: "muted" }, `${job.files.length} CVs, started ${new Date(job.created_at).toLocaleString()}`),
+        jobProgressBar(job),
+        h("p", { class: "muted" }, jobProgressText(job)),
+        job.error ? h("p", { class: "error" }, job.error) : null);
+    app.replaceChildren(summary);
+    if (job.status === "queued" || job.status === "running") {
+        pollTimer = setTimeout(route, 1500);
+        return;
+    }
+    if (job.status !== "done") {
+        return;
+    }
+    const results = await api(`/api/jobs/${id}/results`);
+    const viewNames = Object.keys(results.views).sort();
+    const tabs = h("div", { class: "tabs" });
+    const body = h("div");
+    const selectView = (name) => {
+        for (const b of tabs.children) {
+            b.classList.toggle("active", b.dataset.view === name);
+        }
+        renderViewResults(job, name, results.views[name], body);
+    };
+    tabs.append(...viewNames.map((name) => h("button", { "data-view": name, onclick: () => selectView(name) }, name)));
+    app.append(tabs, body);
+    if (viewNames.length > 0) {
+        selectView(viewNames[0]);
+    }
+}
+
+function renderViewResults(job, viewName, candidates, container) {
+    const checklistKeys = [...new Set(candidates.flatMap((c) => Object.keys(c.checklist)))].sort();
+    const hasTournament = candidates.some((c) => c.tournament_rank);
+    const detail = h("div");
+    const rows = candidates.map((c, i) => {
+        const row = h("tr", {
+            class: "clickable", onclick: () => {
+                for (const r of rows) {
+                    r.classList.remove("selected");
+                }
+                row.classList.add("selected");
+                renderCandidate(job, c, detail);
+            }
+        },
+            h("td", {}, i + 1),
+            h("td", {}, c.file_name, c.duplicates.length > 0 ? h("div", { class: "muted" }, "also submitted " + c.duplicates.join(", ")) : null),
+            h("td", {}, c.final_score),
+            hasTournament ? h("td", { title: c.tournament_rank ? `strength ${c.tournament_score}` : "did not take part" }, c.tournament_rank || "") : null,
+            checklistKeys.map((k) => {
+                const item = c.checklist[k];
+                if (item && item.value !== undefined) {
+                    return h("td", { title: `variance ${item.inconsistency.toFixed(2)}` }, formatValue(item.value));
+                }
+                return item ? h("td", { class: item.passed ? "pass" : "fail", title: `probability ${item.probability.toFixed(2)}` }, item.passed ? "✓" : "✗") : h("td");
+            }));
+        return row;
+    });
+    container.replaceChildren(
+        h("div", { class: "card" },
+            h("div", { class: "row", style: "justify-content: space-between" },
+                h("h3", {}, "Ranked candidates"),
+                h("div", { class: "row" }, ["report", "probabilities", "inconsistency"].map((mode) =>
+                    h("button", { class: "secondary", onclick: () => downloadCSV(job.id, viewName, mode).catch(showError) }, "Download " + mode + " CSV")))),
+            candidates.length === 0 ? h("p", { class: "muted" }, "No candidates were reviewed.") : h("table", {},
+                h("thead", {}, h("tr", {}, h("th", {}, "#"), h("th", {}, "Candidate"), h("th", {}, "Score"), hasTournament ? h("th", { title: "Rank in the pairwise comparison tournament" }, "Tournament") : null, checklistKeys.map((k) => h("th", {}, humanise(k))))),
+                h("tbody", {}, rows))),
+        detail);
+    if (rows.length > 0) {
+        rows[0].click();
+    }
+}
+
+async function renderCandidate(job, candidate, container) {
+    const answers = h("div", {},
+        h("h3", {}, candidate.file_name),
+        candidate.profile ? renderProfile(candidate.profile) : null,
+        h("h4", {}, "Checklist"),
+        Object.entries(candidate.checklist).sort().map(([key, item]) => h("div", { class: "answer" },
+            item.value !== undefined
+                ? h("div", {}, h("strong", {}, humanise(key)), ": " + formatValue(item.value),
+                    h("span", { class: "muted" }, ` (variance ${item.inconsistency.toFixed(2)})`))
+                : h("div", {}, h("span", { class: item.passed ? "pass" : "fail" }, item.passed ? "✓ " : "✗ "), h("strong", {}, humanise(key)),
+                    h("span", { class: "muted" }, ` (probability ${item.probability.toFixed(2)})`)),
+            h("div", { class: "reasoning" }, item.reasoning),
+            (item.evidence || []).map((quote) => h("blockquote", { class: "evidence" }, quote)))),
+        Object.keys(candidate.questions).length > 0 ? h("h4", {}, "Questions") : null,
+        Object.entries(candidate.questions).sort().map(([key, q]) => h("div", { class: "answer" },
+            h("strong", {}, humanise(key)), h("div", {}, q.answer, q.votes ? h("span", { class: "muted" }, ` (votes: ${formatVotes(q.votes)})`) : null),
+            h("div", { class: "reasoning" }, q.reasoning))));
+
+    const textBox = h("div", { class: "candidate-text" }, "Loading text...");
+    const modeSelect = h("select", {},
+        h("option", { value: "reviewed_text" }, "Text sent to the model"),
+        h("option", { value: "text" }, "Extracted text"));
+    container.replaceChildren(h("div", { class: "card split" }, answers, h("div", {}, h("div", { class: "row" }, h("h4", {}, "CV text"), modeSelect), textBox)));
+    try {
+        const file = await api(`/api/jobs/${job.id}/files/${encodeURIComponent(candidate.file_name)}`);
+        const show = () => textBox.textContent = file[modeSelect.value];
+        modeSelect.addEventListener("change", show);
+        show();
+    } catch (err) {
+        textBox.textContent = err.message;
+    }
+}
+
+// ---- Views ----
+
+// editor holds the config being edited, as the raw JSON object from the config file.
+const editor = { config: null, fileName: "", saveDisabled: "", selected: null, errors: [], message: "" };
+
+async function renderViews() {
+    if (editor.config === null) {
+        const resp = await api("/api/config");
+        editor.config = resp.config;
+        editor.fileName = resp.file_name;
+        editor.saveDisabled = resp.save_disabled || "";
+        editor.config.views = editor.config.views || {};
+    }
+    const viewNames = Object.keys(editor.config.views);
+    if (!(editor.selected in editor.config.views)) {
+        editor.selected = viewNames[0] || null;
+    }
+
+    const list = h("div", { class: "tabs" },
+        viewNames.map((name) => h("button", {
+            class: name === editor.selected ? "active" : "", onclick: () => {
+                editor.selected = name;
+                renderViews();
+            }
+        }, editor.config.views[name].pretty_name || name)),
+        h("button", { class: "secondary", onclick: addView }, "+ Add view"),
+        h("button", { class: "secondary", onclick: editRawConfig }, "Edit raw config"));
+
+    const errorList = editor.errors.length === 0 ? null : h("div", { class: "card error" },
+        h("strong", {}, "The config has problems:"),
+        h("ul", {}, editor.errors.map((e) => h("li", {}, e.path ? `${e.path}: ${e.message}` : e.message))));
+
+    app.replaceChildren(
+        h("h2", {}, "Views ", h("span", { class: "muted" }, `(${editor.fileName})`)),
+        list,
+        editor.saveDisabled ? h("p", { class: "muted" }, editor.saveDisabled) : null,
+        errorList,
+        editor.selected ? viewForm(editor.selected) : h("p", { class: "muted" }, "There are no views yet."),
+        h("div", { class: "row" },
+            h("button", { class: "secondary", onclick: () => checkConfig(false) }, "Check"),
+            h("button", { onclick: () => checkConfig(true), disabled: editor.saveDisabled !== "", title: editor.saveDisabled }, "Save"),
+            h("button", {
+                class: "secondary", onclick: () => {
+                    editor.config = null;
+                    editor.errors = [];
+                    editor.message = "";
+                    renderViews();
+                }
+            }, "Discard changes"),
+            h("span", { class: "muted" }, editor.message)),
+    );
+
+    // Highlight the inputs that the errors point at.
+    for (const e of editor.errors) {
+        for (const el of app.querySelectorAll("[data-path]")) {
+            if (el.dataset.path === e.path) {
+                el.classList.add("invalid");
+            }
+        }
+    }
+}
+
+function viewForm(viewName) {
+    const view = editor.config.views[viewName];
+    const viewPath = configPath("views", viewName);
+    view.score_checklist = view.score_checklist || {};
+    view.specific_questions = view.specific_questions || {};
+
+    const bind = (obj, field, input, transform = (v) => v) => {
+        input.addEventListener("input", () => {
+            const v = transform(input.type === "checkbox" ? input.checked : input.value);
+            if (v === undefined || v === "" || v === false) {
+                delete obj[field];
+            } else {
+                obj[field] = v;
+            }
+            editor.message = "Unsaved changes";
+        });
+        return input;
+    };
+
+    const checklistRows = Object.entries(view.score_checklist).map(([key, item]) => {
+        const itemPath = configPath("views", viewName, "score_checklist", key);
+        return h("tr", {},
+            h("td", { class: "key" }, keyInput(view, "score_checklist", key, itemPath)),
+            h("td", {}, bind(item, "question", h("input", { type: "text", value: item.question || "", "data-path": itemPath + ".question" }))),
+            h("td", { class: "narrow" }, bind(item, "weight", h("input", { type: "number", step: "any", value: item.weight ?? "", placeholder: "1", style: "width: 5rem", "data-path": itemPath + ".weight" }),
+                (v) => v === "" ? undefined : Number(v))),
+            h("td", { class: "narrow" }, bind(item, "important", h("input", { type: "checkbox", checked: !!item.important }))),
+            h("td", { class: "narrow" }, h("button", {
+                class: "danger", onclick: () => {
+                    delete view.score_checklist[key];
+                    renderViews();
+                }
+            }, "Delete")));
+    });
+    const questionRows = Object.entries(view.specific_questions).map(([key, q]) => {
+        const qPath = configPath("views", viewName, "specific_questions", key);
+        return h("tr", {},
+            h("td", { class: "key" }, keyInput(view, "specific_questions", key, qPath)),
+            h("td", {}, bind(q, "question", h("input", { type: "text", value: q.question || "", "data-path": qPath + ".question" }))),
+            h("td", { class: "narrow" }, bind(q, "use_original_text", h("input", { type: "checkbox", checked: !!q.use_original_text, "data-path": qPath + ".use_original_text" }))),
+            h("td", { class: "narrow" }, h("button", {
+                class: "danger", onclick: () => {
+                    delete view.specific_questions[key];
+                    renderViews();
+                }
+            }, "Delete")));
+    });
+
+    const nameInput = h("input", { type: "text", value: viewName, "data-path": viewPath });
+    nameInput.addEventListener("change", () => {
+        const newName = nameInput.value.trim();
+        if (newName === viewName || newName === "") {
+            return;
+        }
+        if (newName in editor.config.views) {
+            alert(`There is already a view called ${newName}.`);
+            nameInput.value = viewName;
+            return;
+        }
+        editor.config.views = renameKey(editor.config.views, viewName, newName);
+        editor.selected = newName;
+        renderViews();
+    });
+
+    return h("div", { class: "card" },
+        h("div", { class: "row" },
+            h("label", {}, "Name (used in file names) ", nameInput),
+            h("label", {}, "Display name ", bind(view, "pretty_name", h("input", { type: "text", value: view.pretty_name || "", "data-path": viewPath + ".pretty_name" }))),
+            h("button", {
+                class: "danger", onclick: () => {
+                    if (confirm(`Delete the view ${viewName}?`)) {
+                        delete editor.config.views[viewName];
+                        renderViews();
+                    }
+                }
+            }, "Delete view")),
+        h("h4", {}, "Job description"),
+        h("p", { class: "muted" }, "Either the path of a text, Markdown or PDF file relative to the config file, or the job description written out in full below."),
+        h("label", {}, "File ", bind(view, "job_description_file", h("input", { type: "text", value: view.job_description_file || "", placeholder: "job_descriptions/backend.pdf", "data-path": viewPath + ".job_description_file" }))),
+        bind(view, "job_description", h("textarea", { "data-path": viewPath + ".job_description" }, view.job_description || "")),
+        view.include && view.include.length > 0
+            ? h("p", { class: "muted" }, `Also includes ${view.include.join(", ")}, which can be changed in the raw config.`)
+            : null,
+        h("h4", {}, "Checklist"),
+        h("table", { class: "editor-table" },
+            h("thead", {}, h("tr", {}, h("th", {}, "Key"), h("th", {}, "Question"), h("th", {}, "Weight"), h("th", {}, "Important"), h("th"))),
+            h("tbody", {}, checklistRows)),
+        h("button", { class: "secondary", onclick: () => addItem(view, "score_checklist", { question: "" }) }, "+ Add checklist item"),
+        h("h4", {}, "Specific questions"),
+        h("table", { class: "editor-table" },
+            h("thead", {}, h("tr", {}, h("th", {}, "Key"), h("th", {}, "Question"), h("th", { title: "Answer from the CV before anonymisation" }, "Original text"), h("th"))),
+            h("tbody", {}, questionRows)),
+        h("button", { class: "secondary", onclick: () => addItem(view, "specific_questions", { question: "" }) }, "+ Add question"));
+}
+
+// keyInput is an input that renames a key of view[field] when changed, keeping its position.
+function keyInput(view, field, key, path) {
+    const input = h("input", { type: "text", value: key, "data-path": path });
+    input.addEventListener("change", () => {
+        const newKey = input.value.trim();
+        if (newKey === key || newKey === "") {
+            input.value = key;
+            return;
+        }
+        if (newKey in view[field]) {
+            alert(`The key ${newKey} is already used.`);
+            input.value = key;
+            return;
+        }
+        view[field] = renameKey(view[field], key, newKey);
+        editor.message = "Unsaved changes";
+        renderViews();
+    });
+    return input;
+}
+
+function renameKey(obj, oldKey, newKey) {
+    return Object.fromEntries(Object.entries(obj).map(([k, v]) => [k === oldKey ? newKey : k, v]));
+}
+
+function uniqueKey(obj, base) {
+    let key = base;
+    for (let i = 2; key in obj; i++) {
+        key = `${base}_${i}`;
+    }
+    return key;
+}
+
+function addItem(view, field, value) {
+    view[field][uniqueKey(view[field], "new_item")] = value;
+    editor.message = "Unsaved changes";
+    renderViews();
+}
+
+function addView() {
+    const name = uniqueKey(editor.config.views, "new_view");
+    editor.config.views[name] = { pretty_name: "New view", score_checklist: {}, specific_questions: {} };
+    editor.selected = name;
+    editor.message = "Unsaved changes";
+    renderViews();
+}
+
+function editRawConfig() {
+    const textarea = h("textarea", { class: "code" }, JSON.stringify(editor.config, null, 4));
+    const status = h("span", { class: "error" });
+    app.replaceChildren(
+        h("h2", {}, "Raw config"),
+        h("p", { class: "muted" }, "Edit everything in the config, including groups, includes and overrides. Configs that include group files cannot be saved here."),
+        textarea,
+        h("div", { class: "row" },
+            h("button", {
+                onclick: () => {
+                    try {
+                        editor.config = JSON.parse(textarea.value);
+                        editor.config.views = editor.config.views || {};
+                        editor.message = "Unsaved changes";
+                        renderViews();
+                    } catch (err) {
+                        status.textContent = "Invalid JSON: " + err.message;
+                    }
+                }
+            }, "Apply"),
+            h("button", { class: "secondary", onclick: renderViews }, "Cancel"),
+            status));
+}
+
+// checkConfig validates the edited config on the server, saving it if save is true and it is valid.
+async function checkConfig(save) {
+    try {
+        const resp = await api(save ? "/api/config" : "/api/config/validate", {
+            method: save ? "PUT" : "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify(editor.config),
+        });
+        editor.errors = resp.errors || [];
+        if (resp.valid) {
+            editor.message = save ? "Saved, new scans will use this config." : "No problems found.";
+        } else {
+            editor.message = save ? "Not saved, fix the problems first." : "";
+        }
+    } catch (err) {
+        editor.errors = [{ path: "", message: err.message }];
+        editor.message = "";
+    }
+    renderViews();
+}
+
+// ---- Routing ----
+
+async function route() {
+    clearTimeout(pollTimer);
+    const hash = location.hash || "#/scan";
+    for (const a of document.querySelectorAll("nav a")) {
+        a.classList.toggle("active", hash.startsWith(a.getAttribute("href")));
+    }
+    try {
+        const jobMatch = hash.match(/^#\/jobs\/([0-9a-f]+)$/);
+        if (jobMatch) {
+            await renderJob(jobMatch[1]);
+        } else if (hash === "#/jobs") {
+            await renderJobs();
+        } else if (hash === "#/views") {
+            await renderViews();
+        } else {
+            await renderScan();
+        }
+    } catch (err) {
+        showError(err);
+    }
+}
+
+window.addEventListener("hashchange", route);
+route();